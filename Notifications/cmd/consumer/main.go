@@ -1,20 +1,34 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
 	"github.com/learning-kafka/Notifications/internal/app"
+	"github.com/learning-kafka/Shared/config"
+	"github.com/learning-kafka/Shared/logging"
+	"github.com/learning-kafka/Shared/tracing"
 )
 
 func main() {
-	kafkaBrokers := os.Getenv("KAFKA_BROKERS")
-	if kafkaBrokers == "" {
-		kafkaBrokers = "localhost:9092"
+	if err := logging.Init("notification-service"); err != nil {
+		log.Fatalf("Failed to initialize logging: %v", err)
 	}
 
-	application := app.NewApp(kafkaBrokers)
+	cfg := app.DefaultConfig()
+	if err := config.Load("notification-service", &cfg, os.Args[1:]); err != nil {
+		logging.Fatal("Invalid configuration", "error", err)
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), "notification-service")
+	if err != nil {
+		logging.Fatal("Failed to initialize tracing", "error", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	application := app.NewApp(cfg)
 	if err := application.Run(); err != nil {
-		log.Fatalf("Failed to start application: %v", err)
+		logging.Fatal("Failed to start application", "error", err)
 	}
 }
@@ -1,25 +1,57 @@
 package app
 
 import (
+	"context"
+	"log/slog"
+	"os/signal"
+	"syscall"
+
 	"github.com/learning-kafka/Notifications/internal/kafka"
 	"github.com/learning-kafka/Notifications/internal/service"
+	"github.com/learning-kafka/Shared/admin"
+	"github.com/learning-kafka/Shared/health"
 )
 
 type App struct {
 	kafkaConsumer *kafka.Consumer
 	service       *service.NotificationService
+	health        *health.Checker
+	cfg           Config
 }
 
-func NewApp(kafkaBrokers string) *App {
-	kafkaConsumer := kafka.NewConsumer(kafkaBrokers)
+func NewApp(cfg Config) *App {
+	kafkaConsumer := kafka.NewConsumer(cfg.Kafka, cfg.Retry.Policy())
 	notificationService := service.NewNotificationService()
 
+	checker := health.NewChecker()
+	checker.Add("kafka", kafkaConsumer.Ping)
+	checker.Add("consumer_group", kafkaConsumer.Joined)
+
 	return &App{
 		kafkaConsumer: kafkaConsumer,
 		service:       notificationService,
+		health:        checker,
+		cfg:           cfg,
 	}
 }
 
+// Run consumes payment-events, and serves metrics and health checks on the
+// admin address, until the process receives SIGINT or SIGTERM.
 func (a *App) Run() error {
-	return a.kafkaConsumer.ConsumeMessages("payment-events", a.service.SendNotification)
+	defer a.kafkaConsumer.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	adminServer, err := admin.Listen(a.cfg.AdminAddr, a.health)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := adminServer.Serve(ctx); err != nil {
+			slog.Error("Admin server stopped", "error", err)
+		}
+	}()
+
+	return a.kafkaConsumer.ConsumeMessages(ctx, a.cfg.Kafka.Topics.PaymentEvents, a.service.SendNotification)
 }
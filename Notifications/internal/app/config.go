@@ -0,0 +1,32 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/learning-kafka/Shared/config"
+)
+
+// Config is the notification service's configuration, loaded by
+// config.Load.
+type Config struct {
+	AdminAddr string       `yaml:"admin_addr" env:"ADMIN_ADDR" flag:"admin-addr" usage:"address of the metrics and health check server"`
+	Kafka     config.Kafka `yaml:"kafka"`
+	Retry     config.Retry `yaml:"retry"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		AdminAddr: ":8082",
+		Kafka:     config.DefaultKafka("notification-service"),
+		Retry:     config.DefaultRetry(),
+	}
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.AdminAddr == "" {
+		errs = append(errs, errors.New("admin_addr is required"))
+	}
+	errs = append(errs, c.Kafka.Validate(), c.Retry.Validate())
+	return errors.Join(errs...)
+}
@@ -1,44 +1,99 @@
 package kafka
 
 import (
+	"context"
+	"log/slog"
+
 	"github.com/Shopify/sarama"
+	"github.com/learning-kafka/Shared/config"
+	"github.com/learning-kafka/Shared/health"
+	"github.com/learning-kafka/Shared/metrics"
+	"github.com/learning-kafka/Shared/retry"
 )
 
 type Consumer struct {
-	consumer sarama.Consumer
+	client      sarama.Client
+	group       sarama.ConsumerGroup
+	groupID     string
+	producer    sarama.SyncProducer
+	retries     *retry.Router
+	retryPolicy retry.Policy
+	membership  health.Membership
 }
 
-func NewConsumer(brokers string) *Consumer {
-	config := sarama.NewConfig()
-	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
-	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+func NewConsumer(cfg config.Kafka, retryPolicy retry.Policy) *Consumer {
+	saramaConfig, err := cfg.Sarama()
+	if err != nil {
+		panic(err)
+	}
+	saramaConfig.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	metrics.BridgeSarama("consumer", saramaConfig)
+
+	client, err := cfg.Connect(saramaConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	group, err := sarama.NewConsumerGroupFromClient(cfg.GroupID, client)
+	if err != nil {
+		panic(err)
+	}
 
-	consumer, err := sarama.NewConsumer([]string{brokers}, config)
+	rawProducer, err := sarama.NewSyncProducerFromClient(client)
 	if err != nil {
 		panic(err)
 	}
+	producer := metrics.InstrumentProducer(rawProducer)
 
 	return &Consumer{
-		consumer: consumer,
+		client:      client,
+		group:       group,
+		groupID:     cfg.GroupID,
+		producer:    producer,
+		retries:     retry.NewRouter(producer, retryPolicy, cfg.GroupID),
+		retryPolicy: retryPolicy,
 	}
 }
 
-func (c *Consumer) ConsumeMessages(topic string, handler func([]byte) error) error {
-	partitionConsumer, err := c.consumer.ConsumePartition(topic, 0, sarama.OffsetNewest)
-	if err != nil {
-		return err
+// ConsumeMessages joins the consumer group on topic and its retry topics and
+// passes every message, from all partitions, to handler, with a context
+// carrying the message's trace and correlation IDs, until ctx is cancelled.
+// A restarted consumer resumes after the last committed offset.
+func (c *Consumer) ConsumeMessages(ctx context.Context, topic string, handler func(context.Context, []byte) error) error {
+	groupHandler := &consumerGroupHandler{
+		handler:    handler,
+		groupID:    c.groupID,
+		retries:    c.retries,
+		membership: &c.membership,
 	}
-	defer partitionConsumer.Close()
+	for {
+		if err := c.group.Consume(ctx, c.retryPolicy.Topics(topic), groupHandler); err != nil {
+			slog.Error("Consumer group session failed", "group", c.groupID, "error", err)
+		}
 
-	for msg := range partitionConsumer.Messages() {
-		if err := handler(msg.Value); err != nil {
-			return err
+		if ctx.Err() != nil {
+			return nil
 		}
 	}
+}
 
-	return nil
+// Ping reports whether a broker can be reached.
+func (c *Consumer) Ping(ctx context.Context) error {
+	return health.Brokers(c.client)(ctx)
+}
+
+// Joined reports whether the consumer is currently a member of its group.
+func (c *Consumer) Joined(ctx context.Context) error {
+	return c.membership.Check(ctx)
 }
 
 func (c *Consumer) Close() error {
-	return c.consumer.Close()
+	if err := c.producer.Close(); err != nil {
+		return err
+	}
+	if err := c.group.Close(); err != nil {
+		return err
+	}
+	return c.client.Close()
 }
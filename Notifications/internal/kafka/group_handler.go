@@ -0,0 +1,70 @@
+package kafka
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/learning-kafka/Shared/correlation"
+	"github.com/learning-kafka/Shared/health"
+	"github.com/learning-kafka/Shared/logging"
+	"github.com/learning-kafka/Shared/metrics"
+	"github.com/learning-kafka/Shared/retry"
+	"github.com/learning-kafka/Shared/tracing"
+)
+
+// consumerGroupHandler passes each claimed message to handler and marks it
+// as consumed once it has been handled, so the group's committed offsets only
+// ever cover handled messages. Failed messages are routed through the retry
+// topics and, once their attempts are exhausted, to the dead-letter topic. If
+// that fails too, the session ends without marking the message so that it is
+// redelivered rather than lost.
+type consumerGroupHandler struct {
+	handler    func(context.Context, []byte) error
+	groupID    string
+	retries    *retry.Router
+	membership *health.Membership
+}
+
+func (h *consumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error {
+	h.membership.Joined()
+	return nil
+}
+
+func (h *consumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
+	h.membership.Left()
+	return nil
+}
+
+func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	defer metrics.ForgetClaim(h.groupID, claim)
+
+	for message := range claim.Messages() {
+		metrics.ObserveLag(h.groupID, claim, message)
+
+		if !h.retries.Owns(message) {
+			session.MarkMessage(message, "")
+			continue
+		}
+		if !retry.Wait(session.Context(), message) {
+			return nil
+		}
+
+		start := time.Now()
+		ctx := logging.WithMessage(correlation.FromMessage(context.Background(), message), message)
+		ctx, span := tracing.StartConsumer(ctx, h.groupID, message)
+		err := h.handler(ctx, message.Value)
+		tracing.End(span, err)
+		metrics.ObserveMessage(h.groupID, message, start, err)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to handle message", "group", h.groupID, "error", err)
+			if err := h.retries.Fail(message, err); err != nil {
+				return err
+			}
+		}
+
+		session.MarkMessage(message, "")
+	}
+	return nil
+}
@@ -1,31 +1,61 @@
 package service
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"log/slog"
+
+	"github.com/learning-kafka/Shared/correlation"
+	"github.com/learning-kafka/Shared/events"
+	"github.com/learning-kafka/Shared/retry"
 )
 
 type NotificationService struct{}
 
-type PaymentEvent struct {
-	OrderID string  `json:"order_id"`
-	Status  string  `json:"status"`
-	Amount  float64 `json:"amount"`
-}
-
 func NewNotificationService() *NotificationService {
 	return &NotificationService{}
 }
 
-func (s *NotificationService) SendNotification(message []byte) error {
-	var event PaymentEvent
-	if err := json.Unmarshal(message, &event); err != nil {
-		log.Printf("Failed to unmarshal message: %v", err)
-		return err
+// SendNotification tells the customer about a payment-events message, quoting
+// the correlation ID of the order it concerns as a reference for support.
+// Event types with nothing to tell the customer are logged and skipped.
+func (s *NotificationService) SendNotification(ctx context.Context, message []byte) error {
+	reference, _ := correlation.IDs(ctx)
+
+	meta, err := events.Peek(message)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to decode message", "error", err)
+		return retry.Permanent(err)
 	}
 
-	// Simulate sending notification
-	log.Printf("Sending notification for order %s: Payment %s for amount %.2f",
-		event.OrderID, event.Status, event.Amount)
+	switch meta.EventType {
+	case events.TypePaymentResult, "":
+		var event events.PaymentResult
+		if err := events.Decode(message, &event); err != nil {
+			slog.ErrorContext(ctx, "Failed to decode message", "error", err)
+			return retry.Permanent(err)
+		}
+
+		// Simulate sending notification
+		slog.InfoContext(ctx, "Sending payment notification",
+			"order_id", event.OrderID,
+			"customer_id", event.CustomerID,
+			"payment_status", event.PaymentStatus,
+			"amount", event.TotalAmount.String(),
+			"reference", reference)
+	case events.TypePaymentRefunded:
+		var event events.PaymentRefunded
+		if err := events.Decode(message, &event); err != nil {
+			slog.ErrorContext(ctx, "Failed to decode message", "error", err)
+			return retry.Permanent(err)
+		}
+
+		slog.InfoContext(ctx, "Sending refund notification",
+			"order_id", event.OrderID,
+			"customer_id", event.CustomerID,
+			"amount", event.Amount.String(),
+			"reference", reference)
+	default:
+		slog.WarnContext(ctx, "Skipping unsupported payment event", "event_type", meta.EventType)
+	}
 	return nil
 }
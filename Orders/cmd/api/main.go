@@ -1,21 +1,37 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
-	"strings"
 
 	"github.com/learning-kafka/Orders/internal/app"
+	"github.com/learning-kafka/Shared/config"
+	"github.com/learning-kafka/Shared/logging"
+	"github.com/learning-kafka/Shared/tracing"
 )
 
 func main() {
-	kafkaBrokers := strings.TrimSpace(os.Getenv("KAFKA_BROKERS"))
-	if kafkaBrokers == "" {
-		kafkaBrokers = "localhost:9092"
+	if err := logging.Init("order-service"); err != nil {
+		log.Fatalf("Failed to initialize logging: %v", err)
 	}
 
-	application := app.NewApp(kafkaBrokers)
+	cfg := app.DefaultConfig()
+	if err := config.Load("order-service", &cfg, os.Args[1:]); err != nil {
+		logging.Fatal("Invalid configuration", "error", err)
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), "order-service")
+	if err != nil {
+		logging.Fatal("Failed to initialize tracing", "error", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	application, err := app.NewApp(cfg)
+	if err != nil {
+		logging.Fatal("Failed to initialize application", "error", err)
+	}
 	if err := application.Run(); err != nil {
-		log.Fatalf("Failed to start application: %v", err)
+		logging.Fatal("Failed to start application", "error", err)
 	}
 }
@@ -1,37 +1,129 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/learning-kafka/Orders/internal/handler"
 	"github.com/learning-kafka/Orders/internal/kafka"
+	"github.com/learning-kafka/Orders/internal/middleware"
+	"github.com/learning-kafka/Orders/internal/repository"
 	"github.com/learning-kafka/Orders/internal/service"
+	"github.com/learning-kafka/Orders/internal/stream"
+	"github.com/learning-kafka/Orders/internal/validation"
+	"github.com/learning-kafka/Shared/health"
+	"github.com/learning-kafka/Shared/metrics"
 )
 
 type App struct {
-	router      *gin.Engine
-	kafkaClient *kafka.Client
-	handler     *handler.OrderHandler
-	service     *service.OrderService
+	router        *gin.Engine
+	kafkaClient   *kafka.Client
+	consumer      *kafka.Consumer
+	orders        repository.OrderRepository
+	relay         *kafka.OutboxRelay
+	idempotency   *middleware.Idempotency
+	handler       *handler.OrderHandler
+	outboxHandler *handler.OutboxHandler
+	menuHandler   *handler.MenuHandler
+	service       *service.OrderService
+	health        *health.Checker
+	cfg           Config
 }
 
-func NewApp(kafkaBrokers string) *App {
-	kafkaClient := kafka.NewClient(kafkaBrokers)
-	orderService := service.NewOrderService(kafkaClient)
+func NewApp(cfg Config) (*App, error) {
+	orders, err := repository.Open(cfg.Store.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	kafkaClient := kafka.NewClient(cfg.Kafka)
+	consumer := kafka.NewConsumer(cfg.Kafka, kafkaClient.DeadLetterPublisher("order-service"))
+	relay := kafka.NewOutboxRelay(kafkaClient, orders)
+	idempotency := middleware.NewIdempotency(orders, cfg.Idempotency.TTL)
+	menuService := service.NewMenuService(orders)
+	orderService := service.NewOrderService(orders, menuService, validation.NewValidator(cfg.Limits), stream.NewBroker(), cfg.Kafka.Topics.OrderEvents)
 	orderHandler := handler.NewOrderHandler(orderService)
+	outboxHandler := handler.NewOutboxHandler(relay)
+	menuHandler := handler.NewMenuHandler(menuService)
 
-	router := gin.Default()
+	checker := health.NewChecker()
+	checker.Add("kafka_producer", kafkaClient.Ping)
+	checker.Add("kafka_consumer", consumer.Ping)
+	checker.Add("consumer_group", consumer.Joined)
+	checker.Add("store", func(context.Context) error { return orders.Ping() })
+
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.AccessLog(), middleware.Recovery(), middleware.Tracing(), middleware.Metrics())
 
 	return &App{
-		router:      router,
-		kafkaClient: kafkaClient,
-		handler:     orderHandler,
-		service:     orderService,
-	}
+		router:        router,
+		kafkaClient:   kafkaClient,
+		consumer:      consumer,
+		orders:        orders,
+		relay:         relay,
+		idempotency:   idempotency,
+		handler:       orderHandler,
+		outboxHandler: outboxHandler,
+		menuHandler:   menuHandler,
+		service:       orderService,
+		health:        checker,
+		cfg:           cfg,
+	}, nil
 }
 
+// shutdownTimeout bounds how long Run waits for in-flight requests once
+// the process is asked to stop. Event streams still open after it are cut
+// off; their clients resume from the last event they saw.
+const shutdownTimeout = 10 * time.Second
+
+// Run serves the API, relays the outbox and consumes payment-events until
+// the process receives SIGINT or SIGTERM, then lets in-flight requests
+// finish before closing the store and Kafka clients.
 func (a *App) Run() error {
+	defer a.orders.Close()
+	defer a.kafkaClient.Close()
+	defer a.consumer.Close()
+
+	listener, err := net.Listen("tcp", a.cfg.HTTP.Addr)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go a.relay.Run(ctx)
+	go a.idempotency.Run(ctx)
+	go a.consumer.ConsumeMessages(ctx, a.cfg.Kafka.Topics.PaymentEvents, a.service.HandlePaymentEvent)
+
 	a.setupRoutes()
-	return a.router.Run(":8080")
+	server := &http.Server{Handler: a.router, ReadHeaderTimeout: 5 * time.Second}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); errors.Is(err, context.DeadlineExceeded) {
+		return server.Close()
+	} else if err != nil {
+		return err
+	}
+	return nil
 }
 
 func (a *App) setupRoutes() {
@@ -39,8 +131,28 @@ func (a *App) setupRoutes() {
 	{
 		orders := v1.Group("/orders")
 		{
-			orders.POST("", a.handler.CreateOrder)
+			orders.POST("", a.idempotency.Handler(), a.handler.CreateOrder)
 			orders.GET("", a.handler.GetOrders)
+			orders.GET("/:id", a.handler.GetOrder)
+			orders.POST("/:id/cancel", a.handler.CancelOrder)
+			orders.GET("/:id/events", a.handler.StreamOrderEvents)
+			orders.GET("/:id/events/ws", a.handler.StreamOrderEventsWS)
 		}
+
+		menu := v1.Group("/restaurants/:restaurant_id/menu")
+		{
+			menu.GET("", a.menuHandler.GetMenu)
+			menu.POST("", a.menuHandler.CreateItem)
+			menu.GET("/:item_id", a.menuHandler.GetItem)
+			menu.PUT("/:item_id", a.menuHandler.UpdateItem)
+			menu.DELETE("/:item_id", a.menuHandler.DeleteItem)
+		}
+
+		v1.GET("/outbox", a.outboxHandler.GetStatus)
+		v1.GET("/outbox/quarantine", a.outboxHandler.GetQuarantined)
 	}
+
+	a.router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	a.router.GET("/healthz", gin.WrapH(a.health.LivenessHandler()))
+	a.router.GET("/readyz", gin.WrapH(a.health.ReadinessHandler()))
 }
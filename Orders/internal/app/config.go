@@ -0,0 +1,56 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/learning-kafka/Orders/internal/validation"
+	"github.com/learning-kafka/Shared/config"
+)
+
+// Config is the order service's configuration, loaded by config.Load.
+type Config struct {
+	HTTP        HTTP              `yaml:"http"`
+	Store       Store             `yaml:"store"`
+	Idempotency Idempotency       `yaml:"idempotency"`
+	Limits      validation.Limits `yaml:"limits"`
+	Kafka       config.Kafka      `yaml:"kafka"`
+}
+
+type HTTP struct {
+	Addr string `yaml:"addr" env:"HTTP_ADDR" flag:"http-addr" usage:"address the API listens on"`
+}
+
+type Store struct {
+	Path string `yaml:"path" env:"ORDERS_DB_PATH" flag:"db-path" usage:"order database file, or :memory:"`
+}
+
+type Idempotency struct {
+	TTL time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" flag:"idempotency-ttl" usage:"how long idempotency keys are kept"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		HTTP:        HTTP{Addr: ":8080"},
+		Store:       Store{Path: "orders.db"},
+		Idempotency: Idempotency{TTL: 24 * time.Hour},
+		Limits:      validation.DefaultLimits(),
+		Kafka:       config.DefaultKafka("order-service"),
+	}
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.HTTP.Addr == "" {
+		errs = append(errs, errors.New("http.addr is required"))
+	}
+	if c.Store.Path == "" {
+		errs = append(errs, errors.New("store.path is required"))
+	}
+	if c.Idempotency.TTL <= 0 {
+		errs = append(errs, fmt.Errorf("idempotency.ttl %s: must be positive", c.Idempotency.TTL))
+	}
+	errs = append(errs, c.Limits.Validate(), c.Kafka.Validate())
+	return errors.Join(errs...)
+}
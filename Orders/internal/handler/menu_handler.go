@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/learning-kafka/Orders/internal/model"
+	"github.com/learning-kafka/Orders/internal/repository"
+	"github.com/learning-kafka/Orders/internal/service"
+	"github.com/learning-kafka/Shared/money"
+)
+
+type MenuHandler struct {
+	service *service.MenuService
+}
+
+func NewMenuHandler(service *service.MenuService) *MenuHandler {
+	return &MenuHandler{
+		service: service,
+	}
+}
+
+// menuItemRequest is the body of create and update requests. Prices are in
+// major units of currency, which defaults to money.DefaultCurrency. Items are
+// available unless the request says otherwise.
+type menuItemRequest struct {
+	Name        string      `json:"name" binding:"required"`
+	Description string      `json:"description"`
+	Price       json.Number `json:"price" binding:"required"`
+	Currency    string      `json:"currency"`
+	Available   *bool       `json:"available"`
+}
+
+func (r *menuItemRequest) menuItem(restaurantID, itemID int) (*model.MenuItem, error) {
+	currency := r.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	if !money.ValidCurrency(currency) {
+		return nil, fmt.Errorf("invalid currency %q", r.Currency)
+	}
+
+	price, err := money.Parse(r.Price.String(), currency)
+	if err != nil {
+		return nil, err
+	}
+	if price.Minor <= 0 {
+		return nil, errors.New("price must be positive")
+	}
+
+	return &model.MenuItem{
+		ItemID:       itemID,
+		RestaurantID: restaurantID,
+		Name:         r.Name,
+		Description:  r.Description,
+		Price:        price,
+		Available:    r.Available == nil || *r.Available,
+	}, nil
+}
+
+func (h *MenuHandler) GetMenu(c *gin.Context) {
+	restaurantID, ok := intParam(c, "restaurant_id")
+	if !ok {
+		return
+	}
+
+	items, err := h.service.GetMenu(restaurantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *MenuHandler) CreateItem(c *gin.Context) {
+	restaurantID, ok := intParam(c, "restaurant_id")
+	if !ok {
+		return
+	}
+
+	var request menuItemRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := request.menuItem(restaurantID, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.CreateItem(item); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+func (h *MenuHandler) GetItem(c *gin.Context) {
+	restaurantID, ok := intParam(c, "restaurant_id")
+	if !ok {
+		return
+	}
+	itemID, ok := intParam(c, "item_id")
+	if !ok {
+		return
+	}
+
+	item, err := h.service.GetItem(restaurantID, itemID)
+	if errors.Is(err, repository.ErrMenuItemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// UpdateItem replaces the item, for example to change its price or mark it
+// unavailable. Orders already placed keep the price they were placed at.
+func (h *MenuHandler) UpdateItem(c *gin.Context) {
+	restaurantID, ok := intParam(c, "restaurant_id")
+	if !ok {
+		return
+	}
+	itemID, ok := intParam(c, "item_id")
+	if !ok {
+		return
+	}
+
+	var request menuItemRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := request.menuItem(restaurantID, itemID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = h.service.UpdateItem(item)
+	if errors.Is(err, repository.ErrMenuItemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *MenuHandler) DeleteItem(c *gin.Context) {
+	restaurantID, ok := intParam(c, "restaurant_id")
+	if !ok {
+		return
+	}
+	itemID, ok := intParam(c, "item_id")
+	if !ok {
+		return
+	}
+
+	err := h.service.DeleteItem(restaurantID, itemID)
+	if errors.Is(err, repository.ErrMenuItemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// intParam parses the named path parameter, responding with 400 if it is
+// not an integer.
+func intParam(c *gin.Context, name string) (int, bool) {
+	value, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		return 0, false
+	}
+	return value, true
+}
@@ -1,11 +1,21 @@
 package handler
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/learning-kafka/Orders/internal/model"
+	"github.com/learning-kafka/Orders/internal/problem"
+	"github.com/learning-kafka/Orders/internal/repository"
 	"github.com/learning-kafka/Orders/internal/service"
+	"github.com/learning-kafka/Orders/internal/validation"
 )
 
 type OrderHandler struct {
@@ -18,27 +28,222 @@ func NewOrderHandler(service *service.OrderService) *OrderHandler {
 	}
 }
 
+// createOrderRequest is the body of a create request. It holds only what a
+// client chooses; the ID, status and prices of an order are set by the
+// service, and any such fields in the request are ignored.
+type createOrderRequest struct {
+	CustomerID   int                      `json:"customer_id"`
+	RestaurantID int                      `json:"restaurant_id"`
+	Items        []createOrderItemRequest `json:"items"`
+}
+
+type createOrderItemRequest struct {
+	ItemID   int `json:"item_id"`
+	Quantity int `json:"quantity"`
+}
+
+func (r *createOrderRequest) order() *model.Order {
+	items := make([]model.OrderItem, len(r.Items))
+	for i, item := range r.Items {
+		items[i] = model.OrderItem{ItemID: item.ItemID, Quantity: item.Quantity}
+	}
+
+	return &model.Order{
+		CustomerID:   r.CustomerID,
+		RestaurantID: r.RestaurantID,
+		Items:        items,
+	}
+}
+
+// CreateOrder reports malformed and invalid orders as problem+json.
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
-	var order model.Order
-	if err := c.ShouldBindJSON(&order); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var request createOrderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		problem.Write(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := h.service.CreateOrder(&order); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	order := request.order()
+	var invalid validation.Errors
+	err := h.service.CreateOrder(c.Request.Context(), order)
+	switch {
+	case errors.As(err, &invalid):
+		problem.Validation(c, invalid)
+		return
+	case errors.Is(err, service.ErrInvalidOrderItem):
+		problem.Write(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	case err != nil:
+		problem.Write(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	c.JSON(http.StatusCreated, order)
 }
 
+// GetOrders lists orders filtered by the customer_id, restaurant_id, status,
+// created_from and created_to query parameters, sorted by order date and
+// then ID. At most limit orders are returned; if there are more, the Link
+// header points at the next page, which carries an opaque cursor parameter.
 func (h *OrderHandler) GetOrders(c *gin.Context) {
-	orders, err := h.service.GetOrders()
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orders, next, err := h.service.GetOrders(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if next != nil {
+		query := c.Request.URL.Query()
+		query.Set("cursor", encodeCursor(next))
+		nextURL := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"next\"", nextURL.String()))
+	}
+
 	c.JSON(http.StatusOK, orders)
 }
+
+func (h *OrderHandler) GetOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+
+	order, err := h.service.GetOrder(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelOrder cancels the order. Cancelling an order that is already
+// cancelled is a conflict.
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+
+	order, err := h.service.CancelOrder(c.Request.Context(), id)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, model.ErrIllegalTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+func parseOrderFilter(c *gin.Context) (repository.OrderFilter, error) {
+	filter := repository.OrderFilter{
+		Status: c.Query("status"),
+		Limit:  defaultPageSize,
+	}
+	if filter.Status != "" && !model.ValidStatus(filter.Status) {
+		return filter, fmt.Errorf("invalid status %q", filter.Status)
+	}
+
+	var err error
+	if filter.CustomerID, err = queryInt(c, "customer_id"); err != nil {
+		return filter, err
+	}
+	if filter.RestaurantID, err = queryInt(c, "restaurant_id"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedFrom, err = queryTime(c, "created_from"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = queryTime(c, "created_to"); err != nil {
+		return filter, err
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 || filter.Limit > maxPageSize {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		if filter.After, err = decodeCursor(cursor); err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+	}
+	return filter, nil
+}
+
+func queryInt(c *gin.Context, name string) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return n, nil
+}
+
+func queryTime(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q: want RFC 3339", name, value)
+	}
+	return t, nil
+}
+
+// encodeCursor and decodeCursor convert between a cursor and the opaque
+// string clients pass back to fetch the next page.
+func encodeCursor(cursor *repository.OrderCursor) string {
+	raw := cursor.OrderDate.Format(time.RFC3339Nano) + "|" + strconv.Itoa(cursor.OrderID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (*repository.OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	date, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, errors.New("malformed cursor")
+	}
+
+	cursor := &repository.OrderCursor{}
+	if cursor.OrderDate, err = time.Parse(time.RFC3339Nano, date); err != nil {
+		return nil, err
+	}
+	if cursor.OrderID, err = strconv.Atoi(id); err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/learning-kafka/Orders/internal/model"
+	"github.com/learning-kafka/Orders/internal/repository"
+)
+
+const (
+	// heartbeatInterval is how often an idle stream sends a keep-alive, so
+	// that proxies do not close it and dead clients are noticed.
+	heartbeatInterval = 15 * time.Second
+
+	// writeTimeout bounds how long a WebSocket write may block on a slow
+	// client.
+	writeTimeout = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{}
+
+// StreamOrderEvents streams the order's status transitions as server-sent
+// events, starting with its history. Each event's id is its sequence number,
+// so a reconnecting client that sends Last-Event-ID (or the last_event_id
+// query parameter) resumes after the last event it saw.
+func (h *OrderHandler) StreamOrderEvents(c *gin.Context) {
+	id, after, ok := h.parseStreamRequest(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	send := func(event model.StatusEvent) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: status\ndata: %s\n\n", event.Sequence, data); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+	heartbeat := func() error {
+		if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	if err := h.followOrder(c.Request.Context(), id, after, send, heartbeat); err != nil {
+		slog.WarnContext(c.Request.Context(), "Stopped streaming order events", "order_id", id, "error", err)
+	}
+}
+
+// StreamOrderEventsWS is the WebSocket equivalent of StreamOrderEvents. Each
+// status event is sent as a JSON text message; clients resume with the
+// last_event_id query parameter.
+func (h *OrderHandler) StreamOrderEventsWS(c *gin.Context) {
+	id, after, ok := h.parseStreamRequest(c)
+	if !ok {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already replied to the client.
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	// Clients send nothing, but the connection must be read to process
+	// control frames and to notice when the client goes away.
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(event model.StatusEvent) error {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		return conn.WriteJSON(event)
+	}
+	heartbeat := func() error {
+		return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+	}
+
+	err = h.followOrder(ctx, id, after, send, heartbeat)
+	if err != nil {
+		slog.WarnContext(ctx, "Stopped streaming order events", "order_id", id, "error", err)
+		return
+	}
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeTimeout))
+}
+
+// parseStreamRequest reads the order ID and the sequence number to resume
+// after, and checks that the order exists. It replies to the client and
+// reports false if the request cannot be streamed.
+func (h *OrderHandler) parseStreamRequest(c *gin.Context) (id, after int, ok bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return 0, 0, false
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	if lastEventID != "" {
+		after, err = strconv.Atoi(lastEventID)
+		if err != nil || after < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid last event id %q", lastEventID)})
+			return 0, 0, false
+		}
+	}
+
+	_, err = h.service.GetOrder(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return 0, 0, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, 0, false
+	}
+
+	return id, after, true
+}
+
+// followOrder passes the order's status events after sequence number after
+// to send, and then each new event as the order changes, until ctx is done or
+// a write fails. It subscribes before reading the history, so no transition
+// is missed in between. heartbeat is called whenever the stream has been
+// idle for heartbeatInterval.
+func (h *OrderHandler) followOrder(ctx context.Context, id, after int, send func(model.StatusEvent) error, heartbeat func() error) error {
+	updates, unsubscribe := h.service.WatchOrder(id)
+	defer unsubscribe()
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		events, err := h.service.StatusEvents(id, after)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := send(event); err != nil {
+				return err
+			}
+			after = event.Sequence
+		}
+
+		select {
+		case <-updates:
+		case <-ticker.C:
+			if err := heartbeat(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/learning-kafka/Orders/internal/kafka"
+)
+
+// quarantineListLimit caps how many quarantined messages GetQuarantined
+// returns.
+const quarantineListLimit = 100
+
+type OutboxHandler struct {
+	relay *kafka.OutboxRelay
+}
+
+func NewOutboxHandler(relay *kafka.OutboxRelay) *OutboxHandler {
+	return &OutboxHandler{
+		relay: relay,
+	}
+}
+
+func (h *OutboxHandler) GetStatus(c *gin.Context) {
+	status, err := h.relay.Status()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+func (h *OutboxHandler) GetQuarantined(c *gin.Context) {
+	messages, err := h.relay.Quarantined(quarantineListLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
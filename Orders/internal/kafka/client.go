@@ -1,39 +1,79 @@
 package kafka
 
 import (
+	"context"
+
 	"github.com/Shopify/sarama"
+	"github.com/learning-kafka/Shared/config"
+	"github.com/learning-kafka/Shared/dlq"
+	"github.com/learning-kafka/Shared/health"
+	"github.com/learning-kafka/Shared/metrics"
+	"github.com/learning-kafka/Shared/tracing"
 )
 
 type Client struct {
+	client   sarama.Client
 	producer sarama.SyncProducer
 }
 
-func NewClient(brokers string) *Client {
-	config := sarama.NewConfig()
-	config.Producer.RequiredAcks = sarama.WaitForAll
-	config.Producer.Retry.Max = 5
-	config.Producer.Return.Successes = true
+func NewClient(cfg config.Kafka) *Client {
+	saramaConfig, err := cfg.Sarama()
+	if err != nil {
+		panic(err)
+	}
+	metrics.BridgeSarama("producer", saramaConfig)
+
+	client, err := cfg.Connect(saramaConfig)
+	if err != nil {
+		panic(err)
+	}
 
-	producer, err := sarama.NewSyncProducer([]string{brokers}, config)
+	producer, err := sarama.NewSyncProducerFromClient(client)
 	if err != nil {
 		panic(err)
 	}
 
 	return &Client{
-		producer: producer,
+		client:   client,
+		producer: metrics.InstrumentProducer(producer),
 	}
 }
 
-func (c *Client) PublishMessage(topic string, message []byte) error {
+// Ping reports whether a broker can be reached.
+func (c *Client) Ping(ctx context.Context) error {
+	return health.Brokers(c.client)(ctx)
+}
+
+// PublishMessage sends message to topic with headers, adding ctx's trace
+// context to them. Messages with the same non-empty key are always written to
+// the same partition.
+func (c *Client) PublishMessage(ctx context.Context, topic, key string, headers map[string]string, message []byte) error {
 	msg := &sarama.ProducerMessage{
 		Topic: topic,
 		Value: sarama.ByteEncoder(message),
 	}
+	if key != "" {
+		msg.Key = sarama.StringEncoder(key)
+	}
+	for k, v := range headers {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
 
+	_, span := tracing.StartProducer(ctx, msg)
 	_, _, err := c.producer.SendMessage(msg)
+	tracing.End(span, err)
 	return err
 }
 
+// DeadLetterPublisher returns a dlq.Publisher that sends through this
+// client's producer on behalf of service.
+func (c *Client) DeadLetterPublisher(service string) *dlq.Publisher {
+	return dlq.NewPublisher(c.producer, service)
+}
+
 func (c *Client) Close() error {
-	return c.producer.Close()
+	if err := c.producer.Close(); err != nil {
+		return err
+	}
+	return c.client.Close()
 }
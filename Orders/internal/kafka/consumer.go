@@ -0,0 +1,134 @@
+package kafka
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/learning-kafka/Shared/config"
+	"github.com/learning-kafka/Shared/correlation"
+	"github.com/learning-kafka/Shared/dlq"
+	"github.com/learning-kafka/Shared/health"
+	"github.com/learning-kafka/Shared/logging"
+	"github.com/learning-kafka/Shared/metrics"
+	"github.com/learning-kafka/Shared/tracing"
+)
+
+type Consumer struct {
+	client      sarama.Client
+	group       sarama.ConsumerGroup
+	groupID     string
+	deadLetters *dlq.Publisher
+	membership  health.Membership
+}
+
+func NewConsumer(cfg config.Kafka, deadLetters *dlq.Publisher) *Consumer {
+	saramaConfig, err := cfg.Sarama()
+	if err != nil {
+		panic(err)
+	}
+	saramaConfig.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	metrics.BridgeSarama("consumer", saramaConfig)
+
+	client, err := cfg.Connect(saramaConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	group, err := sarama.NewConsumerGroupFromClient(cfg.GroupID, client)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Consumer{
+		client:      client,
+		group:       group,
+		groupID:     cfg.GroupID,
+		deadLetters: deadLetters,
+	}
+}
+
+// ConsumeMessages joins the consumer group on topic and passes each message
+// to handler, with a context carrying the message's trace and correlation
+// IDs, until ctx is cancelled. Messages the handler fails on are moved to the
+// dead-letter topic so that the partition keeps moving.
+func (c *Consumer) ConsumeMessages(ctx context.Context, topic string, handler func(context.Context, []byte) error) error {
+	groupHandler := &consumerGroupHandler{
+		handler:     handler,
+		groupID:     c.groupID,
+		deadLetters: c.deadLetters,
+		membership:  &c.membership,
+	}
+	for {
+		if err := c.group.Consume(ctx, []string{topic}, groupHandler); err != nil {
+			slog.Error("Consumer group session failed", "group", c.groupID, "error", err)
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// Ping reports whether a broker can be reached.
+func (c *Consumer) Ping(ctx context.Context) error {
+	return health.Brokers(c.client)(ctx)
+}
+
+// Joined reports whether the consumer is currently a member of its group.
+func (c *Consumer) Joined(ctx context.Context) error {
+	return c.membership.Check(ctx)
+}
+
+func (c *Consumer) Close() error {
+	if err := c.group.Close(); err != nil {
+		return err
+	}
+	return c.client.Close()
+}
+
+type consumerGroupHandler struct {
+	handler     func(context.Context, []byte) error
+	groupID     string
+	deadLetters *dlq.Publisher
+	membership  *health.Membership
+}
+
+func (h *consumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error {
+	h.membership.Joined()
+	return nil
+}
+
+func (h *consumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
+	h.membership.Left()
+	return nil
+}
+
+func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	defer metrics.ForgetClaim(h.groupID, claim)
+
+	for message := range claim.Messages() {
+		metrics.ObserveLag(h.groupID, claim, message)
+
+		start := time.Now()
+		ctx := logging.WithMessage(correlation.FromMessage(context.Background(), message), message)
+		ctx, span := tracing.StartConsumer(ctx, h.groupID, message)
+		err := h.handler(ctx, message.Value)
+		tracing.End(span, err)
+		metrics.ObserveMessage(h.groupID, message, start, err)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to handle message", "group", h.groupID, "error", err)
+
+			// If the dead-letter publish fails, end the session without
+			// marking the message so it is redelivered rather than lost.
+			if err := h.deadLetters.Publish(message, err); err != nil {
+				return err
+			}
+		}
+
+		session.MarkMessage(message, "")
+	}
+	return nil
+}
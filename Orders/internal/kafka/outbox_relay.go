@@ -0,0 +1,158 @@
+package kafka
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/learning-kafka/Orders/internal/model"
+	"github.com/learning-kafka/Orders/internal/repository"
+	"github.com/learning-kafka/Shared/tracing"
+)
+
+const (
+	relayBatchSize    = 100
+	relayPollInterval = 500 * time.Millisecond
+	relayMaxBackoff   = 30 * time.Second
+	// relayMaxAttempts is how many times a message may fail to publish while
+	// the brokers are reachable before it is quarantined.
+	relayMaxAttempts = 10
+)
+
+// OutboxRelay publishes outbox messages to Kafka in the order they were
+// written and removes them once the broker has acknowledged them. A crash
+// between publishing and removal re-sends the message, so delivery is
+// at-least-once.
+type OutboxRelay struct {
+	client *Client
+	outbox repository.Outbox
+
+	mu     sync.Mutex
+	status RelayStatus
+}
+
+type RelayStatus struct {
+	Backlog         int       `json:"backlog"`
+	Delivered       int64     `json:"delivered"`
+	Failures        int64     `json:"failures"`
+	Quarantined     int64     `json:"quarantined"`
+	LastError       string    `json:"last_error,omitempty"`
+	LastDeliveredAt time.Time `json:"last_delivered_at"`
+}
+
+func NewOutboxRelay(client *Client, outbox repository.Outbox) *OutboxRelay {
+	return &OutboxRelay{
+		client: client,
+		outbox: outbox,
+	}
+}
+
+// Run drains the outbox until ctx is cancelled. After a failed publish it
+// retries the same message with exponential backoff rather than skipping
+// ahead, so events for an order are not reordered. The exception is a
+// message that has failed relayMaxAttempts times although the brokers can
+// be reached, such as one the broker rejects as too large: it is quarantined
+// so that it stops blocking the messages behind it.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	wait := relayPollInterval
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if err := r.drain(ctx); err != nil {
+			slog.Error("Outbox relay failed", "error", err, "retry_in", wait)
+			wait *= 2
+			if wait > relayMaxBackoff {
+				wait = relayMaxBackoff
+			}
+			continue
+		}
+		wait = relayPollInterval
+	}
+}
+
+// Quarantined lists up to limit quarantined messages, oldest first.
+func (r *OutboxRelay) Quarantined(limit int) ([]model.OutboxMessage, error) {
+	return r.outbox.QuarantinedOutbox(limit)
+}
+
+// Status reports the relay's progress and the current outbox backlog.
+func (r *OutboxRelay) Status() (RelayStatus, error) {
+	backlog, err := r.outbox.OutboxBacklog()
+	if err != nil {
+		return RelayStatus{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := r.status
+	status.Backlog = backlog
+	return status, nil
+}
+
+func (r *OutboxRelay) drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		messages, err := r.outbox.PendingOutbox(relayBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		for _, message := range messages {
+			publishCtx := tracing.Extract(ctx, message.Headers)
+			if err := r.client.PublishMessage(publishCtx, message.Topic, message.Key, message.Headers, message.Payload); err != nil {
+				r.recordFailure(err)
+				if markErr := r.outbox.MarkOutboxFailed(message.ID, err.Error()); markErr != nil {
+					slog.Error("Failed to record outbox failure", "outbox_id", message.ID, "error", markErr)
+				}
+				if message.Attempts+1 < relayMaxAttempts || r.client.Ping(ctx) != nil {
+					return err
+				}
+
+				if err := r.outbox.QuarantineOutbox(message.ID); err != nil {
+					return err
+				}
+				r.recordQuarantine()
+				slog.Error("Quarantined outbox message", "outbox_id", message.ID, "topic", message.Topic, "key", message.Key, "attempts", message.Attempts+1, "error", err)
+				continue
+			}
+
+			if err := r.outbox.MarkOutboxDelivered(message.ID); err != nil {
+				return err
+			}
+			r.recordDelivery()
+		}
+	}
+	return nil
+}
+
+func (r *OutboxRelay) recordDelivery() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.status.Delivered++
+	r.status.LastDeliveredAt = time.Now()
+	r.status.LastError = ""
+}
+
+func (r *OutboxRelay) recordQuarantine() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.status.Quarantined++
+}
+
+func (r *OutboxRelay) recordFailure(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.status.Failures++
+	r.status.LastError = err.Error()
+}
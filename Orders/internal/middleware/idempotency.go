@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/learning-kafka/Orders/internal/model"
+	"github.com/learning-kafka/Orders/internal/problem"
+	"github.com/learning-kafka/Orders/internal/repository"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+	purgeInterval        = time.Hour
+	// reservationLease is how long a key stays reserved for a request whose
+	// response has not been saved yet. It only matters if the service stops
+	// mid-request; otherwise the key is saved or released when it finishes.
+	reservationLease = time.Minute
+)
+
+// Idempotency replays the stored response when a request is retried with the
+// same Idempotency-Key, so clients can safely retry after a timeout. Reusing a
+// key with a different request body is rejected with 422, and a retry that
+// arrives while the original is still being handled gets 409.
+//
+// The key is reserved in the store before the request is handled and
+// released again if the handler fails with a server error or panics. If the
+// service stops mid-request, the reservation lapses after reservationLease
+// rather than blocking the key for the whole TTL.
+type Idempotency struct {
+	store repository.IdempotencyStore
+	ttl   time.Duration
+}
+
+func NewIdempotency(store repository.IdempotencyStore, ttl time.Duration) *Idempotency {
+	return &Idempotency{
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+func (i *Idempotency) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			problem.Write(c, http.StatusBadRequest, "Idempotency-Key is too long.")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			problem.Write(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := c.Request.Method + " " + c.FullPath() + " " + key
+		hash := requestHash(body)
+		held, err := i.store.ReserveIdempotencyRecord(&model.IdempotencyRecord{
+			Key:         scope,
+			RequestHash: hash,
+			ExpiresAt:   time.Now().Add(reservationLease),
+		})
+		switch {
+		case err != nil:
+			problem.Write(c, http.StatusInternalServerError, err.Error())
+			return
+		case held == nil:
+		case held.RequestHash != hash:
+			problem.Write(c, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request body.")
+			return
+		case held.Pending():
+			problem.Write(c, http.StatusConflict, "A request with this Idempotency-Key is already in progress.")
+			return
+		default:
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(held.StatusCode, held.ContentType, held.Body)
+			c.Abort()
+			return
+		}
+
+		// Server errors and panics release the key so that the client can
+		// retry them. The release is deferred because Recovery runs outside
+		// this middleware.
+		handled := false
+		defer func() {
+			if handled {
+				return
+			}
+			if err := i.store.DeleteIdempotencyRecord(scope); err != nil {
+				slog.ErrorContext(c.Request.Context(), "Failed to release idempotency key", "idempotency_key", key, "error", err)
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+		handled = true
+
+		record := &model.IdempotencyRecord{
+			Key:         scope,
+			RequestHash: hash,
+			StatusCode:  writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+			ExpiresAt:   time.Now().Add(i.ttl),
+		}
+		if err := i.store.SaveIdempotencyRecord(record); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to save idempotency record", "idempotency_key", key, "error", err)
+		}
+	}
+}
+
+// Run deletes expired records periodically until ctx is cancelled.
+func (i *Idempotency) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := i.store.PurgeIdempotencyRecords(now); err != nil {
+				slog.Error("Failed to purge idempotency records", "error", err)
+			}
+		}
+	}
+}
+
+// requestHash fingerprints a request body. JSON bodies are re-encoded first
+// so that formatting and key order do not make a retry look different.
+// Numbers are kept as written, since decoding them as float64 would make
+// large IDs that differ only in their last digits hash the same.
+func requestHash(body []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err == nil && !decoder.More() {
+		if canonical, err := json.Marshal(v); err == nil {
+			body = canonical
+		}
+	}
+
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/learning-kafka/Shared/metrics"
+)
+
+// Metrics records the count and duration of every request. Requests are
+// labelled with the route pattern rather than the path, so that order IDs do
+// not create a series each; requests no route matched share one label.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), start)
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/learning-kafka/Shared/correlation"
+)
+
+// RequestID gives every request an ID, taken from its X-Request-ID header
+// or generated if it has none, and echoes it in the response. The ID becomes
+// the correlation ID of every event the request leads to and the causation ID
+// of the events it publishes directly.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(correlation.RequestIDHeader)
+		if !correlation.ValidRequestID(id) {
+			id = correlation.NewID()
+		}
+
+		c.Header(correlation.RequestIDHeader, id)
+		c.Request = c.Request.WithContext(correlation.With(c.Request.Context(), id, id))
+		c.Next()
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/learning-kafka/Shared/tracing"
+)
+
+// Tracing starts a server span for every request, continuing the caller's
+// trace if it sent a traceparent header. Handlers pass the request context
+// on, so the events a request leads to join its trace.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx, span := tracing.StartRequest(c.Request, route)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		tracing.EndRequest(span, c.Writer.Status())
+	}
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// IdempotencyRecord is the response stored for an Idempotency-Key so that a
+// retried request can be answered without being executed again. A record
+// without a StatusCode reserves its key while the first request using it is
+// handled.
+type IdempotencyRecord struct {
+	Key         string    `json:"key"`
+	RequestHash string    `json:"request_hash"`
+	StatusCode  int       `json:"status_code"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Pending reports whether r is a reservation still waiting for its response.
+func (r *IdempotencyRecord) Pending() bool {
+	return r.StatusCode == 0
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/learning-kafka/Shared/money"
+)
+
+// MenuItem is a dish on a restaurant's menu. Orders are priced from the
+// menu, never from prices supplied by the client.
+type MenuItem struct {
+	ItemID       int         `json:"item_id"`
+	RestaurantID int         `json:"restaurant_id"`
+	Name         string      `json:"name"`
+	Description  string      `json:"description"`
+	Price        money.Money `json:"price"`
+	Available    bool        `json:"available"`
+}
+
+func (m MenuItem) MarshalJSON() ([]byte, error) {
+	type menuItem MenuItem
+	return json.Marshal(struct {
+		menuItem
+		Currency string `json:"currency"`
+	}{menuItem(m), money.CurrencyOf(m.Price)})
+}
+
+func (m *MenuItem) UnmarshalJSON(data []byte) error {
+	type menuItem MenuItem
+	aux := struct {
+		*menuItem
+		Currency string `json:"currency"`
+	}{menuItem: (*menuItem)(m)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	money.Stamp(aux.Currency, &m.Price)
+	return nil
+}
@@ -1,9 +1,84 @@
 package model
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/learning-kafka/Shared/events"
+	"github.com/learning-kafka/Shared/money"
+)
+
+type OrderItem struct {
+	OrderItemID int         `json:"order_item_id"`
+	ItemID      int         `json:"item_id"`
+	Quantity    int         `json:"quantity"`
+	Price       money.Money `json:"price"`
+}
+
 type Order struct {
-	ID         string  `json:"id"`
-	CustomerID string  `json:"customer_id"`
-	Amount     float64 `json:"amount"`
-	Status     string  `json:"status"`
-	CreatedAt  string  `json:"created_at"`
+	OrderID      int         `json:"order_id"`
+	CustomerID   int         `json:"customer_id"`
+	RestaurantID int         `json:"restaurant_id"`
+	OrderDate    time.Time   `json:"order_date"`
+	TotalAmount  money.Money `json:"total_amount"`
+	Status       string      `json:"status"`
+	Items        []OrderItem `json:"items"`
+}
+
+// OrderCreatedEvent returns the order-events message announcing this order.
+func (o *Order) OrderCreatedEvent() *events.OrderCreated {
+	items := make([]events.OrderItem, len(o.Items))
+	for i, item := range o.Items {
+		items[i] = events.OrderItem(item)
+	}
+
+	return &events.OrderCreated{
+		OrderID:      o.OrderID,
+		CustomerID:   o.CustomerID,
+		RestaurantID: o.RestaurantID,
+		OrderDate:    o.OrderDate,
+		TotalAmount:  o.TotalAmount,
+		Status:       o.Status,
+		Items:        items,
+	}
+}
+
+// OrderCancelledEvent returns the order-events message announcing that this
+// order was cancelled.
+func (o *Order) OrderCancelledEvent() *events.OrderCancelled {
+	return &events.OrderCancelled{
+		OrderID:      o.OrderID,
+		CustomerID:   o.CustomerID,
+		RestaurantID: o.RestaurantID,
+		TotalAmount:  o.TotalAmount,
+		CancelledAt:  time.Now(),
+	}
+}
+
+// MarshalJSON encodes the order's amounts as bare numbers and records their
+// currency in a "currency" field, like the events in Shared/events.
+func (o Order) MarshalJSON() ([]byte, error) {
+	type order Order
+	return json.Marshal(struct {
+		order
+		Currency string `json:"currency"`
+	}{order(o), money.CurrencyOf(o.TotalAmount)})
+}
+
+func (o *Order) UnmarshalJSON(data []byte) error {
+	type order Order
+	aux := struct {
+		*order
+		Currency string `json:"currency"`
+	}{order: (*order)(o)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	amounts := []*money.Money{&o.TotalAmount}
+	for i := range o.Items {
+		amounts = append(amounts, &o.Items[i].Price)
+	}
+	money.Stamp(aux.Currency, amounts...)
+	return nil
 }
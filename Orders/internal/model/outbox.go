@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// OutboxMessage is a Kafka message recorded in the same transaction as the
+// state change it announces, waiting to be relayed to its topic. Headers
+// holds the event's IDs and the trace context of the request that made the
+// change, so the published message continues its trace.
+type OutboxMessage struct {
+	ID        uint64            `json:"id"`
+	Topic     string            `json:"topic"`
+	Key       string            `json:"key,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Payload   []byte            `json:"payload"`
+	CreatedAt time.Time         `json:"created_at"`
+	Attempts  int               `json:"attempts"`
+	LastError string            `json:"last_error,omitempty"`
+}
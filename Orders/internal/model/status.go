@@ -0,0 +1,44 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	StatusPending       = "PENDING"
+	StatusPaid          = "PAID"
+	StatusPaymentFailed = "PAYMENT_FAILED"
+	StatusCancelled     = "CANCELLED"
+)
+
+var ErrIllegalTransition = errors.New("illegal order status transition")
+
+// transitions lists the statuses each status may move to. Statuses that are
+// absent, such as CANCELLED, are terminal.
+var transitions = map[string][]string{
+	StatusPending:       {StatusPaid, StatusPaymentFailed, StatusCancelled},
+	StatusPaymentFailed: {StatusCancelled},
+	StatusPaid:          {StatusCancelled},
+}
+
+// ValidStatus reports whether status is one of the order statuses above.
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusPaid, StatusPaymentFailed, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Transition moves the order to status, or returns ErrIllegalTransition if
+// the state machine does not allow it.
+func (o *Order) Transition(status string) error {
+	for _, next := range transitions[o.Status] {
+		if next == status {
+			o.Status = status
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrIllegalTransition, o.Status, status)
+}
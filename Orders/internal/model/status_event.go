@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// StatusEvent records a status an order entered. Each order's events are
+// numbered from 1 with no gaps, so a client that has seen event n can ask
+// for everything after it.
+type StatusEvent struct {
+	OrderID    int       `json:"order_id"`
+	Sequence   int       `json:"sequence"`
+	Status     string    `json:"status"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
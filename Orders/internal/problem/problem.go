@@ -0,0 +1,49 @@
+// Package problem writes error responses as RFC 7807 problem details.
+package problem
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/learning-kafka/Orders/internal/validation"
+)
+
+const ContentType = "application/problem+json"
+
+// Details is an RFC 7807 problem details object. InvalidParams is an
+// extension member listing the fields that failed validation.
+type Details struct {
+	Type          string                  `json:"type"`
+	Title         string                  `json:"title"`
+	Status        int                     `json:"status"`
+	Detail        string                  `json:"detail,omitempty"`
+	Instance      string                  `json:"instance,omitempty"`
+	InvalidParams []validation.FieldError `json:"invalid_params,omitempty"`
+}
+
+// Write aborts the request with a problem of the given status.
+func Write(c *gin.Context, status int, detail string) {
+	write(c, Details{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+}
+
+// Validation aborts the request with a 422 problem listing errs.
+func Validation(c *gin.Context, errs validation.Errors) {
+	write(c, Details{
+		Type:          "about:blank",
+		Title:         http.StatusText(http.StatusUnprocessableEntity),
+		Status:        http.StatusUnprocessableEntity,
+		Detail:        "The order failed validation.",
+		InvalidParams: errs,
+	})
+}
+
+func write(c *gin.Context, details Details) {
+	details.Instance = c.Request.URL.Path
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(details.Status, details)
+}
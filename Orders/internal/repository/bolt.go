@@ -0,0 +1,502 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/learning-kafka/Orders/internal/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	ordersBucket       = []byte("orders")
+	outboxBucket       = []byte("outbox")
+	quarantineBucket   = []byte("outbox_quarantine")
+	idempotencyBucket  = []byte("idempotency")
+	menuItemsBucket    = []byte("menu_items")
+	statusEventsBucket = []byte("status_events")
+)
+
+type BoltOrderRepository struct {
+	db *bolt.DB
+}
+
+func NewBoltOrderRepository(path string) (*BoltOrderRepository, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltOrderRepository{
+		db: db,
+	}, nil
+}
+
+func (r *BoltOrderRepository) Create(order *model.Order, outbox OutboxFunc) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		if err := putNewOrder(tx, order); err != nil {
+			return err
+		}
+		return enqueue(tx, order, outbox)
+	})
+}
+
+func (r *BoltOrderRepository) Update(id int, fn func(order *model.Order) error, outbox OutboxFunc) (*model.Order, error) {
+	var order model.Order
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(ordersBucket)
+		data := bucket.Get(itob(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		if err := json.Unmarshal(data, &order); err != nil {
+			return err
+		}
+		if err := unindexOrder(tx, &order); err != nil {
+			return err
+		}
+
+		previousStatus := order.Status
+		if err := fn(&order); err != nil {
+			return err
+		}
+		if order.Status != previousStatus {
+			if err := appendStatusEvent(tx, &order); err != nil {
+				return err
+			}
+		}
+
+		data, err := json.Marshal(order)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(itob(id), data); err != nil {
+			return err
+		}
+		if err := indexOrder(tx, &order); err != nil {
+			return err
+		}
+		return enqueue(tx, &order, outbox)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *BoltOrderRepository) Get(id int) (*model.Order, error) {
+	var order model.Order
+	err := r.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(ordersBucket).Get(itob(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, &order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *BoltOrderRepository) List(filter OrderFilter) ([]model.Order, error) {
+	var orders []model.Order
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		orders, err = queryOrders(tx, filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *BoltOrderRepository) StatusEvents(orderID, after int) ([]model.StatusEvent, error) {
+	events := make([]model.StatusEvent, 0)
+	err := r.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(ordersBucket).Get(itob(orderID)) == nil {
+			return ErrNotFound
+		}
+
+		prefix := itob(orderID)
+		c := tx.Bucket(statusEventsBucket).Cursor()
+		for k, data := c.Seek(statusEventKey(orderID, after+1)); k != nil && bytes.HasPrefix(k, prefix); k, data = c.Next() {
+			var event model.StatusEvent
+			if err := json.Unmarshal(data, &event); err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *BoltOrderRepository) PendingOutbox(limit int) ([]model.OutboxMessage, error) {
+	messages := make([]model.OutboxMessage, 0, limit)
+	err := r.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(outboxBucket).Cursor()
+		for k, data := c.First(); k != nil && len(messages) < limit; k, data = c.Next() {
+			var message model.OutboxMessage
+			if err := json.Unmarshal(data, &message); err != nil {
+				return err
+			}
+			messages = append(messages, message)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *BoltOrderRepository) MarkOutboxDelivered(id uint64) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(outboxBucket).Delete(utob(id))
+	})
+}
+
+func (r *BoltOrderRepository) MarkOutboxFailed(id uint64, reason string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(outboxBucket)
+		data := bucket.Get(utob(id))
+		if data == nil {
+			return nil
+		}
+
+		var message model.OutboxMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			return err
+		}
+		message.Attempts++
+		message.LastError = reason
+
+		data, err := json.Marshal(message)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(utob(id), data)
+	})
+}
+
+func (r *BoltOrderRepository) QuarantineOutbox(id uint64) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		outbox := tx.Bucket(outboxBucket)
+		data := outbox.Get(utob(id))
+		if data == nil {
+			return nil
+		}
+
+		if err := tx.Bucket(quarantineBucket).Put(utob(id), data); err != nil {
+			return err
+		}
+		return outbox.Delete(utob(id))
+	})
+}
+
+func (r *BoltOrderRepository) QuarantinedOutbox(limit int) ([]model.OutboxMessage, error) {
+	messages := make([]model.OutboxMessage, 0)
+	err := r.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(quarantineBucket).Cursor()
+		for k, data := c.First(); k != nil && len(messages) < limit; k, data = c.Next() {
+			var message model.OutboxMessage
+			if err := json.Unmarshal(data, &message); err != nil {
+				return err
+			}
+			messages = append(messages, message)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *BoltOrderRepository) OutboxBacklog() (int, error) {
+	var n int
+	err := r.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(outboxBucket).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+func (r *BoltOrderRepository) ReserveIdempotencyRecord(record *model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing *model.IdempotencyRecord
+	err = r.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(idempotencyBucket)
+		if stored := bucket.Get([]byte(record.Key)); stored != nil {
+			var held model.IdempotencyRecord
+			if err := json.Unmarshal(stored, &held); err != nil {
+				return err
+			}
+			if !time.Now().After(held.ExpiresAt) {
+				existing = &held
+				return nil
+			}
+		}
+		return bucket.Put([]byte(record.Key), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+func (r *BoltOrderRepository) SaveIdempotencyRecord(record *model.IdempotencyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(idempotencyBucket).Put([]byte(record.Key), data)
+	})
+}
+
+func (r *BoltOrderRepository) DeleteIdempotencyRecord(key string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(idempotencyBucket).Delete([]byte(key))
+	})
+}
+
+func (r *BoltOrderRepository) PurgeIdempotencyRecords(now time.Time) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(idempotencyBucket)
+
+		var expired [][]byte
+		err := bucket.ForEach(func(k, data []byte) error {
+			var record model.IdempotencyRecord
+			if err := json.Unmarshal(data, &record); err != nil {
+				return err
+			}
+			if now.After(record.ExpiresAt) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *BoltOrderRepository) CreateMenuItem(item *model.MenuItem) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(menuItemsBucket)
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		item.ItemID = int(id)
+
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(menuKey(item.RestaurantID, item.ItemID), data)
+	})
+}
+
+func (r *BoltOrderRepository) UpdateMenuItem(item *model.MenuItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(menuItemsBucket)
+		key := menuKey(item.RestaurantID, item.ItemID)
+		if bucket.Get(key) == nil {
+			return ErrMenuItemNotFound
+		}
+		return bucket.Put(key, data)
+	})
+}
+
+func (r *BoltOrderRepository) GetMenuItem(restaurantID, itemID int) (*model.MenuItem, error) {
+	var item model.MenuItem
+	err := r.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(menuItemsBucket).Get(menuKey(restaurantID, itemID))
+		if data == nil {
+			return ErrMenuItemNotFound
+		}
+		return json.Unmarshal(data, &item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *BoltOrderRepository) ListMenuItems(restaurantID int) ([]model.MenuItem, error) {
+	items := make([]model.MenuItem, 0)
+	err := r.db.View(func(tx *bolt.Tx) error {
+		prefix := itob(restaurantID)
+		c := tx.Bucket(menuItemsBucket).Cursor()
+		for k, data := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, data = c.Next() {
+			var item model.MenuItem
+			if err := json.Unmarshal(data, &item); err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *BoltOrderRepository) DeleteMenuItem(restaurantID, itemID int) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(menuItemsBucket)
+		key := menuKey(restaurantID, itemID)
+		if bucket.Get(key) == nil {
+			return ErrMenuItemNotFound
+		}
+		return bucket.Delete(key)
+	})
+}
+
+func (r *BoltOrderRepository) Ping() error {
+	return r.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(ordersBucket) == nil {
+			return fmt.Errorf("bucket %q is missing", ordersBucket)
+		}
+		return nil
+	})
+}
+
+func (r *BoltOrderRepository) Close() error {
+	return r.db.Close()
+}
+
+// putNewOrder assigns the order the bucket's next sequence number as its ID
+// and stores and indexes it, starting its status history.
+func putNewOrder(tx *bolt.Tx, order *model.Order) error {
+	bucket := tx.Bucket(ordersBucket)
+	id, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	order.OrderID = int(id)
+
+	data, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+	if err := bucket.Put(itob(order.OrderID), data); err != nil {
+		return err
+	}
+	if err := appendStatusEvent(tx, order); err != nil {
+		return err
+	}
+	return indexOrder(tx, order)
+}
+
+// appendStatusEvent records the order's current status as the next event in
+// its status history.
+func appendStatusEvent(tx *bolt.Tx, order *model.Order) error {
+	bucket := tx.Bucket(statusEventsBucket)
+
+	event := model.StatusEvent{
+		OrderID:    order.OrderID,
+		Sequence:   1,
+		Status:     order.Status,
+		OccurredAt: time.Now(),
+	}
+
+	// The order's latest event is the one just before the next order's
+	// first event, or the last event overall.
+	c := bucket.Cursor()
+	k, _ := c.Seek(statusEventKey(order.OrderID+1, 0))
+	if k == nil {
+		k, _ = c.Last()
+	} else {
+		k, _ = c.Prev()
+	}
+	if k != nil && bytes.HasPrefix(k, itob(order.OrderID)) {
+		event.Sequence = int(binary.BigEndian.Uint64(k[8:])) + 1
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(statusEventKey(order.OrderID, event.Sequence), data)
+}
+
+// enqueue stores the outbox message for order under the outbox bucket's next
+// sequence number, so messages are relayed in the order they were written.
+func enqueue(tx *bolt.Tx, order *model.Order, outbox OutboxFunc) error {
+	if outbox == nil {
+		return nil
+	}
+
+	message, err := outbox(order)
+	if err != nil {
+		return err
+	}
+
+	bucket := tx.Bucket(outboxBucket)
+	message.ID, err = bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	message.CreatedAt = time.Now()
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(utob(message.ID), data)
+}
+
+// statusEventKey groups an order's status events together, in sequence.
+func statusEventKey(orderID, sequence int) []byte {
+	return append(itob(orderID), itob(sequence)...)
+}
+
+// menuKey groups a restaurant's items together, in item ID order.
+func menuKey(restaurantID, itemID int) []byte {
+	return append(itob(restaurantID), itob(itemID)...)
+}
+
+// itob encodes an ID as big-endian so that keys sort in ID order.
+func itob(id int) []byte {
+	return utob(uint64(id))
+}
+
+func utob(id uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, id)
+	return b
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/learning-kafka/Orders/internal/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Secondary indexes over the orders bucket. Every index key ends with the
+// order's sort key (big-endian OrderDate in Unix nanoseconds followed by the
+// big-endian OrderID), so each index lists its orders in List's sort order
+// and its values are empty.
+var (
+	ordersByCreatedBucket    = []byte("orders_by_created")
+	ordersByCustomerBucket   = []byte("orders_by_customer")
+	ordersByRestaurantBucket = []byte("orders_by_restaurant")
+	ordersByStatusBucket     = []byte("orders_by_status")
+)
+
+var epoch = time.Unix(0, 0)
+
+type orderIndex struct {
+	bucket []byte
+	prefix func(order *model.Order) []byte
+}
+
+var orderIndexes = []orderIndex{
+	{ordersByCreatedBucket, func(*model.Order) []byte { return nil }},
+	{ordersByCustomerBucket, func(o *model.Order) []byte { return itob(o.CustomerID) }},
+	{ordersByRestaurantBucket, func(o *model.Order) []byte { return itob(o.RestaurantID) }},
+	{ordersByStatusBucket, func(o *model.Order) []byte { return statusPrefix(o.Status) }},
+}
+
+func indexOrder(tx *bolt.Tx, order *model.Order) error {
+	for _, index := range orderIndexes {
+		key := append(index.prefix(order), sortKey(order.OrderDate, order.OrderID)...)
+		if err := tx.Bucket(index.bucket).Put(key, []byte{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unindexOrder(tx *bolt.Tx, order *model.Order) error {
+	for _, index := range orderIndexes {
+		key := append(index.prefix(order), sortKey(order.OrderDate, order.OrderID)...)
+		if err := tx.Bucket(index.bucket).Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryOrders walks the most selective index for filter and returns the
+// matching orders in sort order.
+func queryOrders(tx *bolt.Tx, filter OrderFilter) ([]model.Order, error) {
+	bucket, prefix := ordersByCreatedBucket, []byte(nil)
+	switch {
+	case filter.CustomerID != 0:
+		bucket, prefix = ordersByCustomerBucket, itob(filter.CustomerID)
+	case filter.RestaurantID != 0:
+		bucket, prefix = ordersByRestaurantBucket, itob(filter.RestaurantID)
+	case filter.Status != "":
+		bucket, prefix = ordersByStatusBucket, statusPrefix(filter.Status)
+	}
+
+	start := append([]byte{}, prefix...)
+	if !filter.CreatedFrom.IsZero() {
+		start = append(start, sortKey(filter.CreatedFrom, 0)...)
+	}
+	if filter.After != nil {
+		if after := append(append([]byte{}, prefix...), sortKey(filter.After.OrderDate, filter.After.OrderID)...); bytes.Compare(after, start) > 0 {
+			start = after
+		}
+	}
+
+	var end []byte
+	if !filter.CreatedTo.IsZero() {
+		end = append(append([]byte{}, prefix...), sortKey(filter.CreatedTo, 0)...)
+	}
+
+	orders := make([]model.Order, 0)
+	ordersData := tx.Bucket(ordersBucket)
+	c := tx.Bucket(bucket).Cursor()
+	for k, _ := c.Seek(start); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		if end != nil && bytes.Compare(k, end) >= 0 {
+			break
+		}
+
+		data := ordersData.Get(k[len(k)-8:])
+		if data == nil {
+			continue
+		}
+
+		var order model.Order
+		if err := json.Unmarshal(data, &order); err != nil {
+			return nil, err
+		}
+		if !filter.matches(&order) {
+			continue
+		}
+
+		orders = append(orders, order)
+		if filter.Limit > 0 && len(orders) == filter.Limit {
+			break
+		}
+	}
+	return orders, nil
+}
+
+// sortKey orders keys by date and then ID. Dates before 1970, including the
+// zero time, sort first.
+func sortKey(date time.Time, id int) []byte {
+	var nanos uint64
+	if date.After(epoch) {
+		nanos = uint64(date.UnixNano())
+	}
+
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b, nanos)
+	binary.BigEndian.PutUint64(b[8:], uint64(id))
+	return b
+}
+
+// statusPrefix is NUL-terminated so that no status is a prefix of another.
+func statusPrefix(status string) []byte {
+	return append([]byte(status), 0)
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/learning-kafka/Orders/internal/model"
+)
+
+// OrderFilter selects the orders returned by List. Zero fields match every
+// order. Orders are returned sorted by OrderDate and then OrderID, starting
+// after After (if set) and stopping after Limit orders (if positive).
+type OrderFilter struct {
+	CustomerID   int
+	RestaurantID int
+	Status       string
+	// CreatedFrom is inclusive and CreatedTo exclusive.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	After       *OrderCursor
+	Limit       int
+}
+
+// OrderCursor is the sort position of an order. Passing the cursor of the
+// last order on a page as OrderFilter.After returns the next page.
+type OrderCursor struct {
+	OrderDate time.Time
+	OrderID   int
+}
+
+func CursorOf(order *model.Order) *OrderCursor {
+	return &OrderCursor{OrderDate: order.OrderDate, OrderID: order.OrderID}
+}
+
+// before reports whether c sorts before order.
+func (c *OrderCursor) before(order *model.Order) bool {
+	if !c.OrderDate.Equal(order.OrderDate) {
+		return c.OrderDate.Before(order.OrderDate)
+	}
+	return c.OrderID < order.OrderID
+}
+
+func (f *OrderFilter) matches(order *model.Order) bool {
+	if f.CustomerID != 0 && order.CustomerID != f.CustomerID {
+		return false
+	}
+	if f.RestaurantID != 0 && order.RestaurantID != f.RestaurantID {
+		return false
+	}
+	if f.Status != "" && order.Status != f.Status {
+		return false
+	}
+	if !f.CreatedFrom.IsZero() && order.OrderDate.Before(f.CreatedFrom) {
+		return false
+	}
+	if !f.CreatedTo.IsZero() && !order.OrderDate.Before(f.CreatedTo) {
+		return false
+	}
+	if f.After != nil && !f.After.before(order) {
+		return false
+	}
+	return true
+}
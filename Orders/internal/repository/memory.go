@@ -0,0 +1,339 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/learning-kafka/Orders/internal/model"
+)
+
+type MemoryOrderRepository struct {
+	mu           sync.RWMutex
+	nextID       int
+	orders       []model.Order
+	nextOutboxID uint64
+	outbox       []model.OutboxMessage
+	quarantine   []model.OutboxMessage
+	idempotency  map[string]model.IdempotencyRecord
+	nextItemID   int
+	menu         map[menuItemKey]model.MenuItem
+	statusEvents map[int][]model.StatusEvent
+}
+
+type menuItemKey struct {
+	restaurantID, itemID int
+}
+
+func NewMemoryOrderRepository() *MemoryOrderRepository {
+	return &MemoryOrderRepository{
+		orders:       make([]model.Order, 0),
+		outbox:       make([]model.OutboxMessage, 0),
+		idempotency:  make(map[string]model.IdempotencyRecord),
+		menu:         make(map[menuItemKey]model.MenuItem),
+		statusEvents: make(map[int][]model.StatusEvent),
+	}
+}
+
+func (r *MemoryOrderRepository) Create(order *model.Order, outbox OutboxFunc) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order.OrderID = r.nextID + 1
+	if err := r.enqueue(order, outbox); err != nil {
+		return err
+	}
+
+	r.nextID++
+	r.orders = append(r.orders, *order)
+	r.appendStatusEvent(order)
+	return nil
+}
+
+func (r *MemoryOrderRepository) Update(id int, fn func(order *model.Order) error, outbox OutboxFunc) (*model.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.orders {
+		if r.orders[i].OrderID != id {
+			continue
+		}
+
+		order := r.orders[i]
+		if err := fn(&order); err != nil {
+			return nil, err
+		}
+		if err := r.enqueue(&order, outbox); err != nil {
+			return nil, err
+		}
+
+		if order.Status != r.orders[i].Status {
+			r.appendStatusEvent(&order)
+		}
+		r.orders[i] = order
+		return &order, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryOrderRepository) Get(id int) (*model.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := range r.orders {
+		if r.orders[i].OrderID == id {
+			order := r.orders[i]
+			return &order, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryOrderRepository) List(filter OrderFilter) ([]model.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := make([]model.Order, 0)
+	for i := range r.orders {
+		if filter.matches(&r.orders[i]) {
+			orders = append(orders, r.orders[i])
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return CursorOf(&orders[i]).before(&orders[j])
+	})
+	if filter.Limit > 0 && len(orders) > filter.Limit {
+		orders = orders[:filter.Limit]
+	}
+	return orders, nil
+}
+
+func (r *MemoryOrderRepository) StatusEvents(orderID, after int) ([]model.StatusEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history, ok := r.statusEvents[orderID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	events := make([]model.StatusEvent, 0)
+	for _, event := range history {
+		if event.Sequence > after {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *MemoryOrderRepository) appendStatusEvent(order *model.Order) {
+	history := r.statusEvents[order.OrderID]
+	r.statusEvents[order.OrderID] = append(history, model.StatusEvent{
+		OrderID:    order.OrderID,
+		Sequence:   len(history) + 1,
+		Status:     order.Status,
+		OccurredAt: time.Now(),
+	})
+}
+
+func (r *MemoryOrderRepository) PendingOutbox(limit int) ([]model.OutboxMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := len(r.outbox)
+	if limit < n {
+		n = limit
+	}
+	messages := make([]model.OutboxMessage, n)
+	copy(messages, r.outbox)
+	return messages, nil
+}
+
+func (r *MemoryOrderRepository) MarkOutboxDelivered(id uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.outbox {
+		if r.outbox[i].ID == id {
+			r.outbox = append(r.outbox[:i], r.outbox[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *MemoryOrderRepository) MarkOutboxFailed(id uint64, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.outbox {
+		if r.outbox[i].ID == id {
+			r.outbox[i].Attempts++
+			r.outbox[i].LastError = reason
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *MemoryOrderRepository) QuarantineOutbox(id uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.outbox {
+		if r.outbox[i].ID == id {
+			r.quarantine = append(r.quarantine, r.outbox[i])
+			r.outbox = append(r.outbox[:i], r.outbox[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *MemoryOrderRepository) QuarantinedOutbox(limit int) ([]model.OutboxMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := len(r.quarantine)
+	if limit < n {
+		n = limit
+	}
+	messages := make([]model.OutboxMessage, n)
+	copy(messages, r.quarantine)
+	return messages, nil
+}
+
+func (r *MemoryOrderRepository) OutboxBacklog() (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.outbox), nil
+}
+
+func (r *MemoryOrderRepository) ReserveIdempotencyRecord(record *model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if held, ok := r.idempotency[record.Key]; ok && !time.Now().After(held.ExpiresAt) {
+		return &held, nil
+	}
+	r.idempotency[record.Key] = *record
+	return nil, nil
+}
+
+func (r *MemoryOrderRepository) SaveIdempotencyRecord(record *model.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.idempotency[record.Key] = *record
+	return nil
+}
+
+func (r *MemoryOrderRepository) DeleteIdempotencyRecord(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.idempotency, key)
+	return nil
+}
+
+func (r *MemoryOrderRepository) PurgeIdempotencyRecords(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, record := range r.idempotency {
+		if now.After(record.ExpiresAt) {
+			delete(r.idempotency, key)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryOrderRepository) Ping() error {
+	return nil
+}
+
+func (r *MemoryOrderRepository) Close() error {
+	return nil
+}
+
+// enqueue appends the outbox message for order; the caller holds r.mu.
+func (r *MemoryOrderRepository) enqueue(order *model.Order, outbox OutboxFunc) error {
+	if outbox == nil {
+		return nil
+	}
+
+	message, err := outbox(order)
+	if err != nil {
+		return err
+	}
+
+	r.nextOutboxID++
+	message.ID = r.nextOutboxID
+	message.CreatedAt = time.Now()
+	r.outbox = append(r.outbox, *message)
+	return nil
+}
+
+func (r *MemoryOrderRepository) CreateMenuItem(item *model.MenuItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextItemID++
+	item.ItemID = r.nextItemID
+	r.menu[menuItemKey{item.RestaurantID, item.ItemID}] = *item
+	return nil
+}
+
+func (r *MemoryOrderRepository) UpdateMenuItem(item *model.MenuItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := menuItemKey{item.RestaurantID, item.ItemID}
+	if _, ok := r.menu[key]; !ok {
+		return ErrMenuItemNotFound
+	}
+	r.menu[key] = *item
+	return nil
+}
+
+func (r *MemoryOrderRepository) GetMenuItem(restaurantID, itemID int) (*model.MenuItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	item, ok := r.menu[menuItemKey{restaurantID, itemID}]
+	if !ok {
+		return nil, ErrMenuItemNotFound
+	}
+	return &item, nil
+}
+
+func (r *MemoryOrderRepository) ListMenuItems(restaurantID int) ([]model.MenuItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := make([]model.MenuItem, 0)
+	for key, item := range r.menu {
+		if key.restaurantID == restaurantID {
+			items = append(items, item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ItemID < items[j].ItemID
+	})
+	return items, nil
+}
+
+func (r *MemoryOrderRepository) DeleteMenuItem(restaurantID, itemID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := menuItemKey{restaurantID, itemID}
+	if _, ok := r.menu[key]; !ok {
+		return ErrMenuItemNotFound
+	}
+	delete(r.menu, key)
+	return nil
+}
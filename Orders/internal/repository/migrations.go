@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/learning-kafka/Orders/internal/model"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	metaBucket       = []byte("meta")
+	schemaVersionKey = []byte("schema_version")
+)
+
+// migrations are applied in order to bring a database up to the current
+// schema. Append new steps; never edit or reorder existing ones.
+var migrations = []func(tx *bolt.Tx) error{
+	// 1: orders keyed by big-endian order ID.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(ordersBucket)
+		return err
+	},
+	// 2: transactional outbox keyed by big-endian sequence number.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(outboxBucket)
+		return err
+	},
+	// 3: idempotency records keyed by request scope and Idempotency-Key.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(idempotencyBucket)
+		return err
+	},
+	// 4: secondary indexes on orders by created-at, customer, restaurant and
+	// status, backfilled from the existing orders.
+	func(tx *bolt.Tx) error {
+		for _, index := range orderIndexes {
+			if _, err := tx.CreateBucketIfNotExists(index.bucket); err != nil {
+				return err
+			}
+		}
+
+		return tx.Bucket(ordersBucket).ForEach(func(_, data []byte) error {
+			var order model.Order
+			if err := json.Unmarshal(data, &order); err != nil {
+				return err
+			}
+			return indexOrder(tx, &order)
+		})
+	},
+	// 5: menu items keyed by big-endian restaurant ID and item ID.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(menuItemsBucket)
+		return err
+	},
+	// 6: order status history keyed by big-endian order ID and sequence,
+	// started for existing orders from their current status.
+	func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(statusEventsBucket); err != nil {
+			return err
+		}
+
+		return tx.Bucket(ordersBucket).ForEach(func(_, data []byte) error {
+			var order model.Order
+			if err := json.Unmarshal(data, &order); err != nil {
+				return err
+			}
+
+			event, err := json.Marshal(model.StatusEvent{
+				OrderID:    order.OrderID,
+				Sequence:   1,
+				Status:     order.Status,
+				OccurredAt: order.OrderDate,
+			})
+			if err != nil {
+				return err
+			}
+			return tx.Bucket(statusEventsBucket).Put(statusEventKey(order.OrderID, 1), event)
+		})
+	},
+	// 7: quarantined outbox messages keyed by their outbox sequence number.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(quarantineBucket)
+		return err
+	},
+}
+
+func migrate(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+
+		var version uint64
+		if data := meta.Get(schemaVersionKey); data != nil {
+			version = binary.BigEndian.Uint64(data)
+		}
+		if version > uint64(len(migrations)) {
+			return fmt.Errorf("database schema version %d is newer than supported version %d", version, len(migrations))
+		}
+
+		for i := version; i < uint64(len(migrations)); i++ {
+			if err := migrations[i](tx); err != nil {
+				return fmt.Errorf("migration %d: %w", i+1, err)
+			}
+		}
+
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint64(data, uint64(len(migrations)))
+		return meta.Put(schemaVersionKey, data)
+	})
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/learning-kafka/Orders/internal/model"
+)
+
+var (
+	ErrNotFound         = errors.New("order not found")
+	ErrMenuItemNotFound = errors.New("menu item not found")
+)
+
+// OutboxFunc builds the outbox message announcing a change to order. It is
+// called inside the transaction that stores the change, after the order has
+// been assigned its ID.
+type OutboxFunc func(order *model.Order) (*model.OutboxMessage, error)
+
+// OrderRepository persists orders. Create assigns the order a new, strictly
+// increasing OrderID before storing it, and enqueues the message returned by
+// outbox (if any) atomically with the order. Update applies fn to the stored
+// order and saves the result in the same way; if fn fails nothing is written.
+// Creating an order, and every update that changes its status, appends a
+// StatusEvent to the order's history in the same transaction. List returns
+// the orders selected by filter. Ping reports whether the store can serve
+// reads.
+type OrderRepository interface {
+	Outbox
+	IdempotencyStore
+	MenuRepository
+	Create(order *model.Order, outbox OutboxFunc) error
+	Update(id int, fn func(order *model.Order) error, outbox OutboxFunc) (*model.Order, error)
+	Get(id int) (*model.Order, error)
+	List(filter OrderFilter) ([]model.Order, error)
+	StatusEvents(orderID, after int) ([]model.StatusEvent, error)
+	Ping() error
+	Close() error
+}
+
+// Outbox holds messages waiting to be published, oldest first.
+// QuarantineOutbox moves a message that cannot be published out of the way
+// of the messages behind it; QuarantinedOutbox lists such messages, oldest
+// first, for an operator to inspect.
+type Outbox interface {
+	PendingOutbox(limit int) ([]model.OutboxMessage, error)
+	MarkOutboxDelivered(id uint64) error
+	MarkOutboxFailed(id uint64, reason string) error
+	QuarantineOutbox(id uint64) error
+	QuarantinedOutbox(limit int) ([]model.OutboxMessage, error)
+	OutboxBacklog() (int, error)
+}
+
+// IdempotencyStore keeps responses keyed by Idempotency-Key.
+// ReserveIdempotencyRecord stores record unless its key is already taken, in
+// which case it returns the record holding the key instead; checking and
+// storing is atomic. Expired records never hold a key and are deleted by
+// PurgeIdempotencyRecords.
+type IdempotencyStore interface {
+	ReserveIdempotencyRecord(record *model.IdempotencyRecord) (*model.IdempotencyRecord, error)
+	SaveIdempotencyRecord(record *model.IdempotencyRecord) error
+	DeleteIdempotencyRecord(key string) error
+	PurgeIdempotencyRecords(now time.Time) error
+}
+
+// MenuRepository holds each restaurant's menu. CreateMenuItem assigns the
+// item a new ItemID, unique across all restaurants. Items are addressed by
+// restaurant and item ID, so an item is never found under another
+// restaurant; ErrMenuItemNotFound is returned instead.
+type MenuRepository interface {
+	CreateMenuItem(item *model.MenuItem) error
+	UpdateMenuItem(item *model.MenuItem) error
+	GetMenuItem(restaurantID, itemID int) (*model.MenuItem, error)
+	ListMenuItems(restaurantID int) ([]model.MenuItem, error)
+	DeleteMenuItem(restaurantID, itemID int) error
+}
+
+// Open returns the repository for path. The special path ":memory:" selects
+// the in-memory implementation; anything else is a bbolt database file.
+func Open(path string) (OrderRepository, error) {
+	if path == ":memory:" {
+		return NewMemoryOrderRepository(), nil
+	}
+	return NewBoltOrderRepository(path)
+}
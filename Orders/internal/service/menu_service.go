@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/learning-kafka/Orders/internal/model"
+	"github.com/learning-kafka/Orders/internal/repository"
+	"github.com/learning-kafka/Shared/money"
+)
+
+// ErrInvalidOrderItem is returned when an order names an item that is not on
+// its restaurant's menu or is currently unavailable.
+var ErrInvalidOrderItem = errors.New("invalid order item")
+
+type MenuService struct {
+	menu repository.MenuRepository
+}
+
+func NewMenuService(menu repository.MenuRepository) *MenuService {
+	return &MenuService{
+		menu: menu,
+	}
+}
+
+func (s *MenuService) CreateItem(item *model.MenuItem) error {
+	return s.menu.CreateMenuItem(item)
+}
+
+func (s *MenuService) UpdateItem(item *model.MenuItem) error {
+	return s.menu.UpdateMenuItem(item)
+}
+
+func (s *MenuService) GetItem(restaurantID, itemID int) (*model.MenuItem, error) {
+	return s.menu.GetMenuItem(restaurantID, itemID)
+}
+
+func (s *MenuService) GetMenu(restaurantID int) ([]model.MenuItem, error) {
+	return s.menu.ListMenuItems(restaurantID)
+}
+
+func (s *MenuService) DeleteItem(restaurantID, itemID int) error {
+	return s.menu.DeleteMenuItem(restaurantID, itemID)
+}
+
+// PriceOrder sets the price of each of the order's items from its
+// restaurant's menu, ignoring any price the client sent, and computes the
+// order's total. It returns ErrInvalidOrderItem if an item is unknown, on
+// another restaurant's menu, unavailable or priced in a different currency
+// from the order's other items.
+func (s *MenuService) PriceOrder(order *model.Order) error {
+	var total money.Money
+	for i := range order.Items {
+		item := &order.Items[i]
+
+		menuItem, err := s.menu.GetMenuItem(order.RestaurantID, item.ItemID)
+		if errors.Is(err, repository.ErrMenuItemNotFound) {
+			return fmt.Errorf("%w: item %d is not on the menu of restaurant %d", ErrInvalidOrderItem, item.ItemID, order.RestaurantID)
+		}
+		if err != nil {
+			return err
+		}
+		if !menuItem.Available {
+			return fmt.Errorf("%w: item %d (%s) is unavailable", ErrInvalidOrderItem, item.ItemID, menuItem.Name)
+		}
+
+		if i == 0 {
+			total = money.New(0, menuItem.Price.Currency)
+		} else if menuItem.Price.Currency != total.Currency {
+			return fmt.Errorf("%w: item %d is priced in %s, not %s", ErrInvalidOrderItem, item.ItemID, menuItem.Price.Currency, total.Currency)
+		}
+
+		item.Price = menuItem.Price
+		total = total.Add(menuItem.Price.Mul(item.Quantity))
+	}
+
+	order.TotalAmount = total
+	return nil
+}
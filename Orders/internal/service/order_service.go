@@ -1,35 +1,201 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
 
-	"github.com/learning-kafka/Orders/internal/kafka"
 	"github.com/learning-kafka/Orders/internal/model"
+	"github.com/learning-kafka/Orders/internal/repository"
+	"github.com/learning-kafka/Orders/internal/stream"
+	"github.com/learning-kafka/Orders/internal/validation"
+	"github.com/learning-kafka/Shared/events"
+	"github.com/learning-kafka/Shared/logging"
+	"github.com/learning-kafka/Shared/tracing"
 )
 
 type OrderService struct {
-	kafkaClient *kafka.Client
-	orders      []model.Order
+	orders    repository.OrderRepository
+	menu      *MenuService
+	validator *validation.Validator
+	watchers  *stream.Broker
+	topic     string
 }
 
-func NewOrderService(kafkaClient *kafka.Client) *OrderService {
+// NewOrderService returns an OrderService whose events are published to
+// topic.
+func NewOrderService(orders repository.OrderRepository, menu *MenuService, validator *validation.Validator, watchers *stream.Broker, topic string) *OrderService {
 	return &OrderService{
-		kafkaClient: kafkaClient,
-		orders:      make([]model.Order, 0),
+		orders:    orders,
+		menu:      menu,
+		validator: validator,
+		watchers:  watchers,
+		topic:     topic,
 	}
 }
 
-func (s *OrderService) CreateOrder(order *model.Order) error {
-	s.orders = append(s.orders, *order)
+// CreateOrder validates the order, prices it from its restaurant's menu and
+// stores it together with its OrderCreated event. The event is published to
+// Kafka asynchronously by the outbox relay. It returns validation.Errors if
+// the order breaks a rule and ErrInvalidOrderItem if it cannot be priced.
+func (s *OrderService) CreateOrder(ctx context.Context, order *model.Order) error {
+	if err := s.validator.ValidateOrder(order); err != nil {
+		return err
+	}
+	if err := s.menu.PriceOrder(order); err != nil {
+		return err
+	}
+	if err := s.validator.ValidateTotal(order); err != nil {
+		return err
+	}
 
-	orderJSON, err := json.Marshal(order)
+	order.Status = model.StatusPending
+	order.OrderDate = time.Now()
+	for i := range order.Items {
+		order.Items[i].OrderItemID = i + 1
+	}
+
+	return s.orders.Create(order, s.outbox(ctx, orderCreated))
+}
+
+// GetOrders returns a page of at most filter.Limit orders matching filter,
+// and the cursor of the next page if there is one.
+func (s *OrderService) GetOrders(filter repository.OrderFilter) ([]model.Order, *repository.OrderCursor, error) {
+	limit := filter.Limit
+	filter.Limit++
+
+	orders, err := s.orders.List(filter)
 	if err != nil {
+		return nil, nil, err
+	}
+	if len(orders) <= limit {
+		return orders, nil, nil
+	}
+
+	orders = orders[:limit]
+	return orders, repository.CursorOf(&orders[limit-1]), nil
+}
+
+func (s *OrderService) GetOrder(id int) (*model.Order, error) {
+	return s.orders.Get(id)
+}
+
+// CancelOrder moves the order to CANCELLED and records its OrderCancelled
+// event, which Payments reacts to by refunding the order. It returns
+// ErrIllegalTransition if the order is already cancelled.
+func (s *OrderService) CancelOrder(ctx context.Context, id int) (*model.Order, error) {
+	order, err := s.orders.Update(id, func(order *model.Order) error {
+		return order.Transition(model.StatusCancelled)
+	}, s.outbox(ctx, orderCancelled))
+	if err != nil {
+		return nil, err
+	}
+
+	s.watchers.Notify(id)
+	return order, nil
+}
+
+// StatusEvents returns the order's status history after sequence number
+// after. It returns repository.ErrNotFound if the order does not exist.
+func (s *OrderService) StatusEvents(id, after int) ([]model.StatusEvent, error) {
+	return s.orders.StatusEvents(id, after)
+}
+
+// WatchOrder returns a channel that receives a value whenever the order's
+// status changes, and a function that stops the notifications.
+func (s *OrderService) WatchOrder(id int) (<-chan struct{}, func()) {
+	return s.watchers.Subscribe(id)
+}
+
+// HandlePaymentEvent applies a payment-events message to its order. Event
+// types this service does not act on are logged and skipped.
+func (s *OrderService) HandlePaymentEvent(ctx context.Context, message []byte) error {
+	meta, err := events.Peek(message)
+	if err != nil {
+		return err
+	}
+
+	switch meta.EventType {
+	case events.TypePaymentResult, "":
+		return s.handlePaymentResult(ctx, message)
+	case events.TypePaymentRefunded:
+		var refund events.PaymentRefunded
+		if err := events.Decode(message, &refund); err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "Payment refunded", "order_id", refund.OrderID, "transaction_id", refund.TransactionID)
+		return nil
+	default:
+		slog.WarnContext(ctx, "Skipping unsupported payment event", "event_type", meta.EventType)
+		return nil
+	}
+}
+
+// handlePaymentResult moves the order named by a PaymentResult to PAID or
+// PAYMENT_FAILED. Transitions the state machine rejects, such as a payment
+// arriving for a cancelled order, are logged and dropped, since redelivering
+// them would never succeed.
+func (s *OrderService) handlePaymentResult(ctx context.Context, message []byte) error {
+	var result events.PaymentResult
+	if err := events.Decode(message, &result); err != nil {
 		return err
 	}
+	ctx = logging.With(ctx, "order_id", result.OrderID)
+
+	status := model.StatusPaid
+	if result.PaymentStatus != events.PaymentStatusCompleted {
+		status = model.StatusPaymentFailed
+	}
+
+	order, err := s.orders.Update(result.OrderID, func(order *model.Order) error {
+		return order.Transition(status)
+	}, nil)
+	switch {
+	case errors.Is(err, model.ErrIllegalTransition):
+		slog.WarnContext(ctx, "Rejected payment result", "error", err)
+		return nil
+	case errors.Is(err, repository.ErrNotFound):
+		slog.WarnContext(ctx, "Received payment result for unknown order")
+		return nil
+	case err != nil:
+		return err
+	}
+
+	s.watchers.Notify(order.OrderID)
+	slog.InfoContext(ctx, "Order status changed", "status", order.Status)
+	return nil
+}
+
+// outbox returns the OutboxFunc that records the event built by event for
+// the order, stamped with ctx's correlation IDs and carrying ctx's trace
+// context in its headers.
+func (s *OrderService) outbox(ctx context.Context, event func(*model.Order) events.Event) repository.OutboxFunc {
+	return func(order *model.Order) (*model.OutboxMessage, error) {
+		e := event(order)
+		events.Correlate(ctx, e)
+		payload, err := events.Encode(e)
+		if err != nil {
+			return nil, err
+		}
+
+		headers := events.Headers(e)
+		for key, value := range tracing.Inject(ctx) {
+			headers[key] = value
+		}
+		return &model.OutboxMessage{
+			Topic:   s.topic,
+			Key:     e.PartitionKey(),
+			Headers: headers,
+			Payload: payload,
+		}, nil
+	}
+}
 
-	return s.kafkaClient.PublishMessage("order-events", orderJSON)
+func orderCreated(order *model.Order) events.Event {
+	return order.OrderCreatedEvent()
 }
 
-func (s *OrderService) GetOrders() ([]model.Order, error) {
-	return s.orders, nil
+func orderCancelled(order *model.Order) events.Event {
+	return order.OrderCancelledEvent()
 }
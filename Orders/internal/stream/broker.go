@@ -0,0 +1,57 @@
+// Package stream wakes up the clients following an order when its status
+// changes.
+package stream
+
+import "sync"
+
+// Broker fans out change notifications per order. Notifications carry no
+// data: a subscriber that wakes up reads the order's status history from
+// the store, so a notification that is coalesced or arrives before the
+// subscriber has caught up loses nothing.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[int]map[chan struct{}]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[int]map[chan struct{}]struct{}),
+	}
+}
+
+// Subscribe returns a channel that receives a value after orderID changes,
+// and a function that must be called to unsubscribe.
+func (b *Broker) Subscribe(orderID int) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscribers[orderID] == nil {
+		b.subscribers[orderID] = make(map[chan struct{}]struct{})
+	}
+	b.subscribers[orderID][ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.subscribers[orderID], ch)
+		if len(b.subscribers[orderID]) == 0 {
+			delete(b.subscribers, orderID)
+		}
+	}
+}
+
+// Notify wakes up every subscriber to orderID without blocking.
+func (b *Broker) Notify(orderID int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[orderID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Package validation checks orders against business rules before they are
+// accepted. The rules live here, rather than in an HTTP handler, so that every
+// path that creates orders applies the same ones.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/learning-kafka/Orders/internal/model"
+	"github.com/learning-kafka/Shared/money"
+)
+
+// FieldError describes one invalid field. Field is a path into the request
+// body, such as "items[2].quantity".
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// Errors is every problem found with an order, not just the first.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	reasons := make([]string, len(e))
+	for i, fe := range e {
+		reasons[i] = fe.Field + ": " + fe.Reason
+	}
+	return "invalid order: " + strings.Join(reasons, "; ")
+}
+
+// Limits bounds the size of a single order. MaxTotal is a decimal amount in
+// the order's own currency.
+type Limits struct {
+	MaxItems    int    `yaml:"max_items" env:"ORDER_MAX_ITEMS" flag:"order-max-items" usage:"most items an order may have"`
+	MaxQuantity int    `yaml:"max_quantity" env:"ORDER_MAX_QUANTITY" flag:"order-max-quantity" usage:"largest quantity of one item"`
+	MaxTotal    string `yaml:"max_total" env:"ORDER_MAX_TOTAL" flag:"order-max-total" usage:"largest order total, in the order's currency"`
+}
+
+func DefaultLimits() Limits {
+	return Limits{
+		MaxItems:    50,
+		MaxQuantity: 100,
+		MaxTotal:    "10000",
+	}
+}
+
+// Validate checks that every limit is positive.
+func (l Limits) Validate() error {
+	var errs []error
+	if l.MaxItems < 1 {
+		errs = append(errs, fmt.Errorf("limits.max_items %d: want a positive integer", l.MaxItems))
+	}
+	if l.MaxQuantity < 1 {
+		errs = append(errs, fmt.Errorf("limits.max_quantity %d: want a positive integer", l.MaxQuantity))
+	}
+	if m, err := money.Parse(l.MaxTotal, money.DefaultCurrency); err != nil || m.Minor <= 0 {
+		errs = append(errs, fmt.Errorf("limits.max_total %q: want a positive amount", l.MaxTotal))
+	}
+	return errors.Join(errs...)
+}
+
+type Validator struct {
+	limits Limits
+}
+
+func NewValidator(limits Limits) *Validator {
+	return &Validator{
+		limits: limits,
+	}
+}
+
+// ValidateOrder checks an order as submitted, before it is priced. It
+// returns Errors if any rule is broken.
+func (v *Validator) ValidateOrder(order *model.Order) error {
+	var errs Errors
+	if order.CustomerID <= 0 {
+		errs = append(errs, FieldError{"customer_id", "must be a positive ID"})
+	}
+	if order.RestaurantID <= 0 {
+		errs = append(errs, FieldError{"restaurant_id", "must be a positive ID"})
+	}
+
+	switch {
+	case len(order.Items) == 0:
+		errs = append(errs, FieldError{"items", "must contain at least one item"})
+	case len(order.Items) > v.limits.MaxItems:
+		errs = append(errs, FieldError{"items", fmt.Sprintf("must contain at most %d items", v.limits.MaxItems)})
+	}
+
+	seen := make(map[int]int, len(order.Items))
+	for i, item := range order.Items {
+		field := fmt.Sprintf("items[%d]", i)
+		if item.ItemID <= 0 {
+			errs = append(errs, FieldError{field + ".item_id", "must be a positive ID"})
+		} else if first, ok := seen[item.ItemID]; ok {
+			errs = append(errs, FieldError{field + ".item_id", fmt.Sprintf("duplicates items[%d]; combine them into one item", first)})
+		} else {
+			seen[item.ItemID] = i
+		}
+
+		if item.Quantity < 1 || item.Quantity > v.limits.MaxQuantity {
+			errs = append(errs, FieldError{field + ".quantity", fmt.Sprintf("must be between 1 and %d", v.limits.MaxQuantity)})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateTotal checks an order's total once it has been priced.
+func (v *Validator) ValidateTotal(order *model.Order) error {
+	max, err := money.Parse(v.limits.MaxTotal, order.TotalAmount.Currency)
+	if err != nil {
+		return err
+	}
+
+	if order.TotalAmount.Minor <= 0 {
+		return Errors{{"total_amount", "must be positive"}}
+	}
+	if order.TotalAmount.Minor > max.Minor {
+		return Errors{{"total_amount", fmt.Sprintf("%s exceeds the maximum order total of %s", order.TotalAmount, max)}}
+	}
+	return nil
+}
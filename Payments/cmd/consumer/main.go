@@ -1,20 +1,47 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
 	"github.com/learning-kafka/Payments/internal/app"
+	"github.com/learning-kafka/Payments/internal/gateway"
+	"github.com/learning-kafka/Shared/config"
+	"github.com/learning-kafka/Shared/logging"
+	"github.com/learning-kafka/Shared/tracing"
 )
 
 func main() {
-	kafkaBrokers := os.Getenv("KAFKA_BROKERS")
-	if kafkaBrokers == "" {
-		kafkaBrokers = "localhost:9092"
+	if err := logging.Init("payment-service"); err != nil {
+		log.Fatalf("Failed to initialize logging: %v", err)
 	}
 
-	application := app.NewApp(kafkaBrokers)
+	cfg := app.DefaultConfig()
+	if err := config.Load("payment-service", &cfg, os.Args[1:]); err != nil {
+		logging.Fatal("Invalid configuration", "error", err)
+	}
+
+	var rules []gateway.Rule
+	if cfg.Gateway.RulesFile != "" {
+		loaded, err := gateway.LoadRules(cfg.Gateway.RulesFile)
+		if err != nil {
+			logging.Fatal("Failed to load payment gateway rules", "error", err)
+		}
+		rules = loaded
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), "payment-service")
+	if err != nil {
+		logging.Fatal("Failed to initialize tracing", "error", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	application, err := app.NewApp(cfg, gateway.NewFakeGateway(cfg.Gateway.Latency, rules))
+	if err != nil {
+		logging.Fatal("Failed to initialize application", "error", err)
+	}
 	if err := application.Run(); err != nil {
-		log.Fatalf("Failed to start application: %v", err)
+		logging.Fatal("Failed to start application", "error", err)
 	}
 }
@@ -1,25 +1,67 @@
 package app
 
 import (
+	"context"
+	"log/slog"
+	"os/signal"
+	"syscall"
+
+	"github.com/learning-kafka/Payments/internal/gateway"
 	"github.com/learning-kafka/Payments/internal/kafka"
+	"github.com/learning-kafka/Payments/internal/repository"
 	"github.com/learning-kafka/Payments/internal/service"
+	"github.com/learning-kafka/Shared/admin"
+	"github.com/learning-kafka/Shared/health"
 )
 
 type App struct {
 	kafkaClient *kafka.Client
+	payments    repository.PaymentRepository
 	service     *service.PaymentService
+	health      *health.Checker
+	cfg         Config
 }
 
-func NewApp(kafkaBrokers string) *App {
-	kafkaClient := kafka.NewClient(kafkaBrokers)
-	paymentService := service.NewPaymentService(kafkaClient)
+func NewApp(cfg Config, gw gateway.PaymentGateway) (*App, error) {
+	payments, err := repository.Open(cfg.Store.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	kafkaClient := kafka.NewClient(cfg.Kafka, cfg.Retry.Policy(), cfg.Workers)
+	paymentService := service.NewPaymentService(kafkaClient, payments, gw, cfg.Kafka.Topics.PaymentEvents)
+
+	checker := health.NewChecker()
+	checker.Add("kafka", kafkaClient.Ping)
+	checker.Add("consumer_group", kafkaClient.Joined)
 
 	return &App{
 		kafkaClient: kafkaClient,
+		payments:    payments,
 		service:     paymentService,
-	}
+		health:      checker,
+		cfg:         cfg,
+	}, nil
 }
 
+// Run consumes order-events, and serves metrics and health checks on the
+// admin address, until the process receives SIGINT or SIGTERM.
 func (a *App) Run() error {
-	return a.kafkaClient.ConsumeMessages("order-events", a.service.ProcessPayment)
+	defer a.payments.Close()
+	defer a.kafkaClient.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	adminServer, err := admin.Listen(a.cfg.AdminAddr, a.health)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := adminServer.Serve(ctx); err != nil {
+			slog.Error("Admin server stopped", "error", err)
+		}
+	}()
+
+	return a.kafkaClient.ConsumeMessages(ctx, a.cfg.Kafka.Topics.OrderEvents, a.service.HandleOrderEvent)
 }
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/learning-kafka/Shared/config"
+)
+
+// Config is the payment service's configuration, loaded by config.Load.
+type Config struct {
+	AdminAddr string       `yaml:"admin_addr" env:"ADMIN_ADDR" flag:"admin-addr" usage:"address of the metrics and health check server"`
+	Workers   int          `yaml:"workers" env:"PAYMENT_WORKERS" flag:"workers" usage:"orders processed at once"`
+	Store     Store        `yaml:"store"`
+	Gateway   Gateway      `yaml:"gateway"`
+	Kafka     config.Kafka `yaml:"kafka"`
+	Retry     config.Retry `yaml:"retry"`
+}
+
+type Store struct {
+	Path string `yaml:"path" env:"PAYMENTS_DB_PATH" flag:"db-path" usage:"payment database file, or :memory:"`
+}
+
+// Gateway configures the fake payment gateway.
+type Gateway struct {
+	Latency   time.Duration `yaml:"latency" env:"PAYMENT_GATEWAY_LATENCY" flag:"gateway-latency" usage:"time each gateway call takes"`
+	RulesFile string        `yaml:"rules_file" env:"PAYMENT_GATEWAY_RULES_FILE" flag:"gateway-rules-file" usage:"JSON file of scripted gateway outcomes"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		AdminAddr: ":8081",
+		Workers:   8,
+		Store:     Store{Path: "payments.db"},
+		Gateway:   Gateway{Latency: 2 * time.Second},
+		Kafka:     config.DefaultKafka("payment-service"),
+		Retry:     config.DefaultRetry(),
+	}
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.AdminAddr == "" {
+		errs = append(errs, errors.New("admin_addr is required"))
+	}
+	if c.Workers < 1 {
+		errs = append(errs, fmt.Errorf("workers %d: want at least 1", c.Workers))
+	}
+	if c.Store.Path == "" {
+		errs = append(errs, errors.New("store.path is required"))
+	}
+	if c.Gateway.Latency < 0 {
+		errs = append(errs, fmt.Errorf("gateway.latency %s: must not be negative", c.Gateway.Latency))
+	}
+	errs = append(errs, c.Kafka.Validate(), c.Retry.Validate())
+	return errors.Join(errs...)
+}
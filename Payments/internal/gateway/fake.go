@@ -0,0 +1,280 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/learning-kafka/Shared/money"
+)
+
+const (
+	OpAuthorize = "authorize"
+	OpCapture   = "capture"
+	OpVoid      = "void"
+	OpRefund    = "refund"
+)
+
+const (
+	OutcomeDecline = "decline"
+	OutcomeTimeout = "timeout"
+	OutcomeError   = "error"
+)
+
+// Rule scripts the fake gateway's response to matching operations. Zero
+// fields match anything, so a Rule with only Outcome set applies to every
+// call. The first matching rule wins.
+type Rule struct {
+	Operation  string  `json:"operation,omitempty"`
+	CustomerID int     `json:"customer_id,omitempty"`
+	MinAmount  float64 `json:"min_amount,omitempty"`
+	MaxAmount  float64 `json:"max_amount,omitempty"`
+	Outcome    string  `json:"outcome"`
+	Code       string  `json:"code,omitempty"`
+	Temporary  bool    `json:"temporary,omitempty"`
+}
+
+// LoadRules reads a JSON array of rules from path. A rule with an unknown
+// operation or outcome is an error rather than a rule that never fires.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parse gateway rules %s: %w", path, err)
+	}
+	for i, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("gateway rules %s: rule %d: %w", path, i+1, err)
+		}
+	}
+	return rules, nil
+}
+
+func (r Rule) validate() error {
+	switch r.Operation {
+	case "", OpAuthorize, OpCapture, OpVoid, OpRefund:
+	default:
+		return fmt.Errorf("unknown operation %q, want %s, %s, %s or %s", r.Operation, OpAuthorize, OpCapture, OpVoid, OpRefund)
+	}
+
+	switch r.Outcome {
+	case OutcomeDecline, OutcomeTimeout, OutcomeError:
+	default:
+		return fmt.Errorf("unknown outcome %q, want %s, %s or %s", r.Outcome, OutcomeDecline, OutcomeTimeout, OutcomeError)
+	}
+	return nil
+}
+
+// FakeGateway is an in-process PaymentGateway for local development. Every
+// call takes latency to complete and succeeds unless a rule says otherwise.
+// A timeout outcome blocks until the caller's context expires. Idempotency
+// keys and captures are remembered until the process exits.
+type FakeGateway struct {
+	latency time.Duration
+	rules   []Rule
+	nextID  atomic.Int64
+
+	mu             sync.Mutex
+	keys           map[string]string
+	authorizations map[string]fakeAuthorization
+	captures       map[string]*Transaction
+	transactions   map[string]*Transaction
+}
+
+type fakeAuthorization struct {
+	Authorization
+	req ChargeRequest
+}
+
+func NewFakeGateway(latency time.Duration, rules []Rule) *FakeGateway {
+	return &FakeGateway{
+		latency:        latency,
+		rules:          rules,
+		keys:           make(map[string]string),
+		authorizations: make(map[string]fakeAuthorization),
+		captures:       make(map[string]*Transaction),
+		transactions:   make(map[string]*Transaction),
+	}
+}
+
+func (g *FakeGateway) Authorize(ctx context.Context, req ChargeRequest) (*Authorization, error) {
+	if auth, ok := g.authorizationFor(req.IdempotencyKey); ok {
+		return auth, nil
+	}
+	if err := g.simulate(ctx, OpAuthorize, req); err != nil {
+		return nil, err
+	}
+
+	auth := Authorization{
+		ID:     g.newID("AUTH"),
+		Amount: req.Amount,
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.authorizations[auth.ID] = fakeAuthorization{Authorization: auth, req: req}
+	if req.IdempotencyKey != "" {
+		g.keys[req.IdempotencyKey] = auth.ID
+	}
+	return &auth, nil
+}
+
+func (g *FakeGateway) Capture(ctx context.Context, authorizationID string) (*Transaction, error) {
+	g.mu.Lock()
+	captured, ok := g.captures[authorizationID]
+	g.mu.Unlock()
+	if ok {
+		return captured, nil
+	}
+
+	auth, err := g.authorization(authorizationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.simulate(ctx, OpCapture, auth.req); err != nil {
+		return nil, err
+	}
+
+	txn := &Transaction{
+		ID:              g.newID("TXN"),
+		AuthorizationID: authorizationID,
+		Amount:          auth.Amount,
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.authorizations, authorizationID)
+	g.captures[authorizationID] = txn
+	g.transactions[txn.ID] = txn
+	return txn, nil
+}
+
+func (g *FakeGateway) Void(ctx context.Context, authorizationID string) error {
+	auth, err := g.authorization(authorizationID)
+	if err != nil {
+		return err
+	}
+	if err := g.simulate(ctx, OpVoid, auth.req); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.authorizations, authorizationID)
+	delete(g.keys, auth.req.IdempotencyKey)
+	return nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, transactionID string, amount money.Money) error {
+	g.mu.Lock()
+	txn, ok := g.transactions[transactionID]
+	g.mu.Unlock()
+
+	// Transactions from before a restart are unknown to the fake; refund
+	// them anyway so the refund path can still be exercised.
+	req := ChargeRequest{Amount: amount}
+	if ok {
+		req.Amount = txn.Amount
+	}
+	return g.simulate(ctx, OpRefund, req)
+}
+
+// authorizationFor returns the authorization made with key, if it has not
+// been voided.
+func (g *FakeGateway) authorizationFor(key string) (*Authorization, bool) {
+	if key == "" {
+		return nil, false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id, ok := g.keys[key]
+	if !ok {
+		return nil, false
+	}
+	if auth, ok := g.authorizations[id]; ok {
+		return &auth.Authorization, true
+	}
+	if txn, ok := g.captures[id]; ok {
+		return &Authorization{ID: id, Amount: txn.Amount}, true
+	}
+	return nil, false
+}
+
+func (g *FakeGateway) authorization(id string) (fakeAuthorization, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, ok := g.authorizations[id]
+	if !ok {
+		return fakeAuthorization{}, &Error{Code: CodeAuthorizationNotFound, Message: id}
+	}
+	return auth, nil
+}
+
+// simulate waits for the configured latency and then applies the first rule
+// matching op and req, if any.
+func (g *FakeGateway) simulate(ctx context.Context, op string, req ChargeRequest) error {
+	select {
+	case <-ctx.Done():
+		return &Error{Code: CodeTimeout, Temporary: true}
+	case <-time.After(g.latency):
+	}
+
+	for _, rule := range g.rules {
+		if !rule.matches(op, req) {
+			continue
+		}
+
+		switch rule.Outcome {
+		case OutcomeDecline:
+			return &Error{Code: orDefault(rule.Code, CodeDeclined)}
+		case OutcomeTimeout:
+			<-ctx.Done()
+			return &Error{Code: CodeTimeout, Temporary: true}
+		case OutcomeError:
+			return &Error{Code: orDefault(rule.Code, "processing_error"), Temporary: rule.Temporary}
+		}
+	}
+	return nil
+}
+
+func (r Rule) matches(op string, req ChargeRequest) bool {
+	if r.Operation != "" && r.Operation != op {
+		return false
+	}
+	if r.CustomerID != 0 && r.CustomerID != req.CustomerID {
+		return false
+	}
+	amount := req.Amount.Float64()
+	if amount < r.MinAmount {
+		return false
+	}
+	if r.MaxAmount != 0 && amount > r.MaxAmount {
+		return false
+	}
+	return true
+}
+
+func (g *FakeGateway) newID(prefix string) string {
+	return fmt.Sprintf("%s-%s-%d", prefix, time.Now().Format("20060102150405"), g.nextID.Add(1))
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
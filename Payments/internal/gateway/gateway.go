@@ -0,0 +1,84 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/learning-kafka/Shared/money"
+)
+
+// Error codes returned by gateways in Error.Code.
+const (
+	CodeDeclined              = "card_declined"
+	CodeInsufficientFunds     = "insufficient_funds"
+	CodeTimeout               = "gateway_timeout"
+	CodeAuthorizationNotFound = "authorization_not_found"
+	CodeTransactionNotFound   = "transaction_not_found"
+)
+
+// Error is a failure reported by the payment provider. Temporary errors, such
+// as timeouts, may succeed if the same operation is retried; all others are
+// final for the payment.
+type Error struct {
+	Code      string
+	Message   string
+	Temporary bool
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return "payment gateway: " + e.Code
+	}
+	return fmt.Sprintf("payment gateway: %s: %s", e.Code, e.Message)
+}
+
+// ChargeRequest describes a charge. Repeating an Authorize with the same
+// IdempotencyKey returns the original authorization instead of holding the
+// customer's funds again, so a charge whose outcome was lost can be retried.
+type ChargeRequest struct {
+	IdempotencyKey string
+	OrderID        int
+	CustomerID     int
+	Amount         money.Money
+}
+
+type Authorization struct {
+	ID     string
+	Amount money.Money
+}
+
+type Transaction struct {
+	ID              string
+	AuthorizationID string
+	Amount          money.Money
+}
+
+// PaymentGateway is the interface to a payment provider. Funds are first
+// authorized, then captured; an authorization that will not be captured
+// should be voided, and a captured transaction can be refunded.
+type PaymentGateway interface {
+	Authorize(ctx context.Context, req ChargeRequest) (*Authorization, error)
+	Capture(ctx context.Context, authorizationID string) (*Transaction, error)
+	Void(ctx context.Context, authorizationID string) error
+	Refund(ctx context.Context, transactionID string, amount money.Money) error
+}
+
+// Charge authorizes and captures req in one step. If the capture fails the
+// authorization is voided so the customer's funds are released. Capturing an
+// authorization that was already captured returns its original transaction,
+// so retrying Charge with the same IdempotencyKey charges at most once.
+func Charge(ctx context.Context, gw PaymentGateway, req ChargeRequest) (*Transaction, error) {
+	auth, err := gw.Authorize(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	txn, err := gw.Capture(ctx, auth.ID)
+	if err != nil {
+		if voidErr := gw.Void(context.WithoutCancel(ctx), auth.ID); voidErr != nil {
+			return nil, fmt.Errorf("%w (void of %s also failed: %v)", err, auth.ID, voidErr)
+		}
+		return nil, err
+	}
+	return txn, nil
+}
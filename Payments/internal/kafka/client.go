@@ -1,67 +1,121 @@
 package kafka
 
 import (
+	"context"
+	"log/slog"
+
 	"github.com/Shopify/sarama"
+	"github.com/learning-kafka/Shared/config"
+	"github.com/learning-kafka/Shared/health"
+	"github.com/learning-kafka/Shared/metrics"
+	"github.com/learning-kafka/Shared/retry"
+	"github.com/learning-kafka/Shared/tracing"
 )
 
 type Client struct {
-	consumer sarama.Consumer
-	producer sarama.SyncProducer
+	client      sarama.Client
+	group       sarama.ConsumerGroup
+	groupID     string
+	producer    sarama.SyncProducer
+	retries     *retry.Router
+	retryPolicy retry.Policy
+	workers     int
+	membership  health.Membership
 }
 
-func NewClient(brokers string) *Client {
-	config := sarama.NewConfig()
-	config.Producer.RequiredAcks = sarama.WaitForAll
-	config.Producer.Retry.Max = 5
-	config.Producer.Return.Successes = true
-	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
-	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+func NewClient(cfg config.Kafka, retryPolicy retry.Policy, workers int) *Client {
+	saramaConfig, err := cfg.Sarama()
+	if err != nil {
+		panic(err)
+	}
+	saramaConfig.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	metrics.BridgeSarama("client", saramaConfig)
 
-	producer, err := sarama.NewSyncProducer([]string{brokers}, config)
+	client, err := cfg.Connect(saramaConfig)
 	if err != nil {
 		panic(err)
 	}
 
-	consumer, err := sarama.NewConsumer([]string{brokers}, config)
+	rawProducer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		panic(err)
+	}
+	producer := metrics.InstrumentProducer(rawProducer)
+
+	group, err := sarama.NewConsumerGroupFromClient(cfg.GroupID, client)
 	if err != nil {
 		panic(err)
 	}
 
 	return &Client{
-		consumer: consumer,
-		producer: producer,
+		client:      client,
+		group:       group,
+		groupID:     cfg.GroupID,
+		producer:    producer,
+		retries:     retry.NewRouter(producer, retryPolicy, cfg.GroupID),
+		retryPolicy: retryPolicy,
+		workers:     workers,
 	}
 }
 
-func (c *Client) ConsumeMessages(topic string, handler func([]byte) error) error {
-	partitionConsumer, err := c.consumer.ConsumePartition(topic, 0, sarama.OffsetNewest)
-	if err != nil {
-		return err
-	}
-	defer partitionConsumer.Close()
+// ConsumeMessages joins the consumer group on topic and its retry topics and
+// passes every message, from all partitions, to handler, with a context
+// carrying the message's trace and correlation IDs, until ctx is cancelled.
+// Up to workers messages with distinct keys are handled at once. A restarted
+// consumer resumes after the last committed offset.
+func (c *Client) ConsumeMessages(ctx context.Context, topic string, handler func(context.Context, []byte) error) error {
+	groupHandler := newConsumerGroupHandler(handler, c.groupID, c.retries, c.workers, &c.membership)
+	defer groupHandler.close()
 
-	for msg := range partitionConsumer.Messages() {
-		if err := handler(msg.Value); err != nil {
-			return err
+	for {
+		if err := c.group.Consume(ctx, c.retryPolicy.Topics(topic), groupHandler); err != nil {
+			slog.Error("Consumer group session failed", "group", c.groupID, "error", err)
 		}
-	}
 
-	return nil
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
 }
 
-func (c *Client) PublishMessage(topic string, message []byte) error {
+// PublishMessage sends message to topic with headers, adding ctx's trace
+// context to them. Messages with the same non-empty key are always written to
+// the same partition.
+func (c *Client) PublishMessage(ctx context.Context, topic, key string, headers map[string]string, message []byte) error {
 	msg := &sarama.ProducerMessage{
 		Topic: topic,
 		Value: sarama.ByteEncoder(message),
 	}
+	if key != "" {
+		msg.Key = sarama.StringEncoder(key)
+	}
+	for k, v := range headers {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
 
+	_, span := tracing.StartProducer(ctx, msg)
 	_, _, err := c.producer.SendMessage(msg)
+	tracing.End(span, err)
 	return err
 }
 
+// Ping reports whether a broker can be reached.
+func (c *Client) Ping(ctx context.Context) error {
+	return health.Brokers(c.client)(ctx)
+}
+
+// Joined reports whether the consumer is currently a member of its group.
+func (c *Client) Joined(ctx context.Context) error {
+	return c.membership.Check(ctx)
+}
+
 func (c *Client) Close() error {
 	if err := c.producer.Close(); err != nil {
 		return err
 	}
-	return c.consumer.Close()
+	if err := c.group.Close(); err != nil {
+		return err
+	}
+	return c.client.Close()
 }
@@ -0,0 +1,102 @@
+package kafka
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/learning-kafka/Shared/correlation"
+	"github.com/learning-kafka/Shared/health"
+	"github.com/learning-kafka/Shared/logging"
+	"github.com/learning-kafka/Shared/metrics"
+	"github.com/learning-kafka/Shared/retry"
+	"github.com/learning-kafka/Shared/tracing"
+)
+
+// consumerGroupHandler passes claimed messages to handler on a worker pool,
+// so that messages for different keys are handled concurrently while each
+// key's messages are still handled in order. A message is only marked as
+// consumed once it and every earlier message in its claim have been handled,
+// so the group's committed offsets only ever cover handled messages. Failed
+// messages are routed through the retry topics and, once their attempts are
+// exhausted, to the dead-letter topic. If that fails too, the session ends
+// without marking the message so that it is redelivered rather than lost.
+type consumerGroupHandler struct {
+	handler    func(context.Context, []byte) error
+	groupID    string
+	retries    *retry.Router
+	pool       *workerPool
+	membership *health.Membership
+}
+
+func newConsumerGroupHandler(handler func(context.Context, []byte) error, groupID string, retries *retry.Router, workers int, membership *health.Membership) *consumerGroupHandler {
+	h := &consumerGroupHandler{handler: handler, groupID: groupID, retries: retries, membership: membership}
+	h.pool = newWorkerPool(workers, h.handle)
+	return h
+}
+
+func (h *consumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error {
+	h.membership.Joined()
+	return nil
+}
+
+func (h *consumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
+	h.membership.Left()
+	return nil
+}
+
+func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	tracker := newOffsetTracker(session, h.pool.size()*pendingPerWorker)
+	defer metrics.ForgetClaim(h.groupID, claim)
+
+	for message := range claim.Messages() {
+		if tracker.Err() != nil {
+			break
+		}
+		metrics.ObserveLag(h.groupID, claim, message)
+
+		tracked := tracker.add(ctx, message)
+		if tracked == nil {
+			break
+		}
+		if !h.retries.Owns(message) {
+			tracker.complete(tracked, nil)
+			continue
+		}
+		if !retry.Wait(ctx, message) {
+			tracker.abandon(tracked)
+			break
+		}
+
+		if !h.pool.submit(ctx, message, func(err error) { tracker.complete(tracked, err) }) {
+			tracker.abandon(tracked)
+			break
+		}
+	}
+
+	// Let in-flight messages finish so that their offsets are marked before
+	// the session commits and the partition is handed over.
+	tracker.wait()
+	return tracker.Err()
+}
+
+func (h *consumerGroupHandler) handle(message *sarama.ConsumerMessage) error {
+	start := time.Now()
+	ctx := logging.WithMessage(correlation.FromMessage(context.Background(), message), message)
+	ctx, span := tracing.StartConsumer(ctx, h.groupID, message)
+	err := h.handler(ctx, message.Value)
+	tracing.End(span, err)
+	metrics.ObserveMessage(h.groupID, message, start, err)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to handle message", "group", h.groupID, "error", err)
+		return h.retries.Fail(message, err)
+	}
+	return nil
+}
+
+// close stops the worker pool after any in-flight messages are handled.
+func (h *consumerGroupHandler) close() {
+	h.pool.close()
+}
@@ -0,0 +1,177 @@
+package kafka
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	"github.com/Shopify/sarama"
+)
+
+// pendingPerWorker bounds how many messages a claim may have dispatched but
+// not yet marked, per worker, so that one slow key cannot make the claim
+// buffer the rest of its partition.
+const pendingPerWorker = 16
+
+// workerPool handles messages on a fixed set of workers. Messages are sharded
+// by key, so messages with the same key are handled one at a time and in the
+// order they were submitted, while different keys are handled in parallel.
+type workerPool struct {
+	shards []chan job
+	wg     sync.WaitGroup
+}
+
+type job struct {
+	message *sarama.ConsumerMessage
+	done    func(error)
+}
+
+func newWorkerPool(workers int, handle func(*sarama.ConsumerMessage) error) *workerPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &workerPool{shards: make([]chan job, workers)}
+	for i := range p.shards {
+		shard := make(chan job, 1)
+		p.shards[i] = shard
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for j := range shard {
+				j.done(handle(j.message))
+			}
+		}()
+	}
+	return p
+}
+
+func (p *workerPool) size() int {
+	return len(p.shards)
+}
+
+// submit queues message on its key's worker and calls done with the result
+// once it has been handled. It reports false, without calling done, if ctx
+// is cancelled while waiting for the worker.
+func (p *workerPool) submit(ctx context.Context, message *sarama.ConsumerMessage, done func(error)) bool {
+	select {
+	case p.shards[p.shard(message)] <- job{message: message, done: done}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// shard picks the worker for message. Unkeyed messages have no ordering
+// requirement and are spread by offset.
+func (p *workerPool) shard(message *sarama.ConsumerMessage) int {
+	h := fnv.New32a()
+	if len(message.Key) > 0 {
+		h.Write(message.Key)
+	} else {
+		h.Write([]byte(strconv.FormatInt(message.Offset, 10)))
+	}
+	return int(h.Sum32() % uint32(len(p.shards)))
+}
+
+// close stops the workers once every submitted message has been handled.
+func (p *workerPool) close() {
+	for _, shard := range p.shards {
+		close(shard)
+	}
+	p.wg.Wait()
+}
+
+// offsetTracker marks a claim's messages as consumed in offset order, and
+// only once every earlier message in the claim has been handled, so that the
+// committed offset never skips a message that is still in flight or failed.
+type offsetTracker struct {
+	session sarama.ConsumerGroupSession
+	slots   chan struct{}
+	failed  chan struct{}
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	pending []*trackedMessage
+	err     error
+}
+
+type trackedMessage struct {
+	message *sarama.ConsumerMessage
+	done    bool
+}
+
+func newOffsetTracker(session sarama.ConsumerGroupSession, maxPending int) *offsetTracker {
+	return &offsetTracker{
+		session: session,
+		slots:   make(chan struct{}, maxPending),
+		failed:  make(chan struct{}),
+	}
+}
+
+// add starts tracking message, waiting while the claim already has the
+// maximum number of unmarked messages. It returns nil if ctx is cancelled
+// or a message fails first.
+func (t *offsetTracker) add(ctx context.Context, message *sarama.ConsumerMessage) *trackedMessage {
+	select {
+	case t.slots <- struct{}{}:
+	case <-t.failed:
+		return nil
+	case <-ctx.Done():
+		return nil
+	}
+
+	tracked := &trackedMessage{message: message}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending = append(t.pending, tracked)
+	t.wg.Add(1)
+	return tracked
+}
+
+// complete records the outcome of a tracked message and marks every message
+// at the head of the claim that has now been handled. A failed message is
+// never marked, which holds back the claim's offset until it is redelivered.
+func (t *offsetTracker) complete(tracked *trackedMessage, err error) {
+	defer t.wg.Done()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err != nil {
+		if t.err == nil {
+			t.err = err
+			close(t.failed)
+		}
+		return
+	}
+
+	tracked.done = true
+	for len(t.pending) > 0 && t.pending[0].done {
+		t.session.MarkMessage(t.pending[0].message, "")
+		t.pending = t.pending[1:]
+		<-t.slots
+	}
+}
+
+// abandon stops waiting for a tracked message that was never handed to a
+// worker. It stays unmarked.
+func (t *offsetTracker) abandon(*trackedMessage) {
+	t.wg.Done()
+}
+
+// Err returns the first failure reported to complete.
+func (t *offsetTracker) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// wait blocks until every tracked message has completed or been abandoned.
+func (t *offsetTracker) wait() {
+	t.wg.Wait()
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/learning-kafka/Shared/events"
+	bolt "go.etcd.io/bbolt"
+)
+
+var paymentsBucket = []byte("payments")
+
+type BoltPaymentRepository struct {
+	db *bolt.DB
+}
+
+func NewBoltPaymentRepository(path string) (*BoltPaymentRepository, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltPaymentRepository{
+		db: db,
+	}, nil
+}
+
+func (r *BoltPaymentRepository) Get(orderID int) (*events.PaymentResult, error) {
+	var result events.PaymentResult
+	err := r.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(paymentsBucket).Get(itob(orderID))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, &result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *BoltPaymentRepository) Save(result *events.PaymentResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(paymentsBucket).Put(itob(result.OrderID), data)
+	})
+}
+
+func (r *BoltPaymentRepository) Close() error {
+	return r.db.Close()
+}
+
+// itob encodes an ID as big-endian so that keys sort in ID order.
+func itob(id int) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id))
+	return b
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/learning-kafka/Shared/events"
+)
+
+type MemoryPaymentRepository struct {
+	mu       sync.RWMutex
+	payments map[int]events.PaymentResult
+}
+
+func NewMemoryPaymentRepository() *MemoryPaymentRepository {
+	return &MemoryPaymentRepository{
+		payments: make(map[int]events.PaymentResult),
+	}
+}
+
+func (r *MemoryPaymentRepository) Get(orderID int) (*events.PaymentResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result, ok := r.payments[orderID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &result, nil
+}
+
+func (r *MemoryPaymentRepository) Save(result *events.PaymentResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.payments[result.OrderID] = *result
+	return nil
+}
+
+func (r *MemoryPaymentRepository) Close() error {
+	return nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	metaBucket       = []byte("meta")
+	schemaVersionKey = []byte("schema_version")
+)
+
+// migrations are applied in order to bring a database up to the current
+// schema. Append new steps; never edit or reorder existing ones.
+var migrations = []func(tx *bolt.Tx) error{
+	// 1: payment results keyed by big-endian order ID.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(paymentsBucket)
+		return err
+	},
+}
+
+func migrate(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+
+		var version uint64
+		if data := meta.Get(schemaVersionKey); data != nil {
+			version = binary.BigEndian.Uint64(data)
+		}
+		if version > uint64(len(migrations)) {
+			return fmt.Errorf("database schema version %d is newer than supported version %d", version, len(migrations))
+		}
+
+		for i := version; i < uint64(len(migrations)); i++ {
+			if err := migrations[i](tx); err != nil {
+				return fmt.Errorf("migration %d: %w", i+1, err)
+			}
+		}
+
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint64(data, uint64(len(migrations)))
+		return meta.Put(schemaVersionKey, data)
+	})
+}
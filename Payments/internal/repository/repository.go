@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/learning-kafka/Shared/events"
+)
+
+var ErrNotFound = errors.New("payment not found")
+
+// PaymentRepository records the result of every processed order so that a
+// redelivered order-events message is answered with the original result
+// instead of charging the customer again.
+type PaymentRepository interface {
+	Get(orderID int) (*events.PaymentResult, error)
+	Save(result *events.PaymentResult) error
+	Close() error
+}
+
+// Open returns the repository for path. The special path ":memory:" selects
+// the in-memory implementation; anything else is a bbolt database file.
+func Open(path string) (PaymentRepository, error) {
+	if path == ":memory:" {
+		return NewMemoryPaymentRepository(), nil
+	}
+	return NewBoltPaymentRepository(path)
+}
@@ -1,54 +1,227 @@
 package service
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
 
+	"github.com/learning-kafka/Payments/internal/gateway"
 	"github.com/learning-kafka/Payments/internal/kafka"
+	"github.com/learning-kafka/Payments/internal/repository"
+	"github.com/learning-kafka/Shared/correlation"
+	"github.com/learning-kafka/Shared/events"
+	"github.com/learning-kafka/Shared/logging"
+	"github.com/learning-kafka/Shared/retry"
+)
+
+// gatewayTimeout bounds each charge attempt against the payment gateway.
+const gatewayTimeout = 10 * time.Second
+
+// Statuses recorded in place of a stored result's PaymentStatus once its
+// order is cancelled. They are never published as a PaymentResult.
+const (
+	// statusRefunded marks a completed payment that has been refunded.
+	statusRefunded = "REFUNDED"
+	// statusCancelled marks an order cancelled before it was charged, so
+	// that a late or retried OrderCreated does not charge it.
+	statusCancelled = "CANCELLED"
 )
 
 type PaymentService struct {
 	kafkaClient *kafka.Client
+	payments    repository.PaymentRepository
+	gateway     gateway.PaymentGateway
+	topic       string
 }
 
-type Order struct {
-	ID     string  `json:"id"`
-	Amount float64 `json:"amount"`
+// NewPaymentService returns a PaymentService that publishes payment events
+// to topic.
+func NewPaymentService(kafkaClient *kafka.Client, payments repository.PaymentRepository, gw gateway.PaymentGateway, topic string) *PaymentService {
+	return &PaymentService{
+		kafkaClient: kafkaClient,
+		payments:    payments,
+		gateway:     gw,
+		topic:       topic,
+	}
 }
 
-type PaymentEvent struct {
-	OrderID string  `json:"order_id"`
-	Status  string  `json:"status"`
-	Amount  float64 `json:"amount"`
+// HandleOrderEvent charges created orders and refunds cancelled ones. Event
+// types this service does not act on are logged and skipped.
+func (s *PaymentService) HandleOrderEvent(ctx context.Context, message []byte) error {
+	meta, err := events.Peek(message)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+
+	switch meta.EventType {
+	case events.TypeOrderCreated, "":
+		return s.ProcessPayment(ctx, message)
+	case events.TypeOrderCancelled:
+		return s.RefundPayment(ctx, message)
+	default:
+		slog.WarnContext(ctx, "Skipping unsupported order event", "event_type", meta.EventType)
+		return nil
+	}
 }
 
-func NewPaymentService(kafkaClient *kafka.Client) *PaymentService {
-	return &PaymentService{
-		kafkaClient: kafkaClient,
+// ProcessPayment charges the order and publishes the result. An order that
+// has already been processed is not charged again; its original result is
+// re-published instead.
+func (s *PaymentService) ProcessPayment(ctx context.Context, message []byte) error {
+	var order events.OrderCreated
+	if err := events.Decode(message, &order); err != nil {
+		return retry.Permanent(err)
 	}
+	ctx = logging.With(ctx, "order_id", order.OrderID)
+
+	if result, err := s.payments.Get(order.OrderID); err == nil {
+		if result.PaymentStatus == statusRefunded || result.PaymentStatus == statusCancelled {
+			slog.InfoContext(ctx, "Order was cancelled, not charging it")
+			return nil
+		}
+		slog.InfoContext(ctx, "Order already processed, re-publishing result", "transaction_id", result.TransactionID)
+		return s.publishResult(ctx, result)
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Processing payment", "amount", order.TotalAmount.String())
+
+	paymentEvent := &events.PaymentResult{
+		OrderID:      order.OrderID,
+		CustomerID:   order.CustomerID,
+		RestaurantID: order.RestaurantID,
+		TotalAmount:  order.TotalAmount,
+	}
+
+	gatewayCtx, cancel := context.WithTimeout(ctx, gatewayTimeout)
+	defer cancel()
+
+	// Declines are a final answer and are recorded like any other result;
+	// temporary gateway errors are returned so the message is retried. The
+	// idempotency key makes the gateway answer a redelivered order with its
+	// original charge if the service stopped before recording the result.
+	txn, err := gateway.Charge(gatewayCtx, s.gateway, gateway.ChargeRequest{
+		IdempotencyKey: "charge-" + strconv.Itoa(order.OrderID),
+		OrderID:        order.OrderID,
+		CustomerID:     order.CustomerID,
+		Amount:         order.TotalAmount,
+	})
+	var gwErr *gateway.Error
+	switch {
+	case err == nil:
+		paymentEvent.PaymentStatus = events.PaymentStatusCompleted
+		paymentEvent.TransactionID = txn.ID
+	case errors.As(err, &gwErr) && !gwErr.Temporary:
+		slog.WarnContext(ctx, "Payment declined", "error", err)
+		paymentEvent.PaymentStatus = events.PaymentStatusFailed
+		paymentEvent.FailureReason = gwErr.Code
+	default:
+		return err
+	}
+	paymentEvent.ProcessedAt = time.Now()
+	paymentEvent.EventID = correlation.NewID()
+
+	// Record the result, with its event ID, before publishing so that a
+	// redelivered order re-publishes the same event rather than a new one.
+	if err := s.payments.Save(paymentEvent); err != nil {
+		return err
+	}
+
+	return s.publishResult(ctx, paymentEvent)
 }
 
-func (s *PaymentService) ProcessPayment(message []byte) error {
-	var order Order
-	if err := json.Unmarshal(message, &order); err != nil {
+// RefundPayment returns the payment taken for a cancelled order and publishes
+// a PaymentRefunded event. Charges are captured as soon as they are
+// authorized, so there is never an open authorization left to void. Orders
+// whose payment failed need no refund; orders not yet charged are recorded
+// as cancelled so that they never will be.
+func (s *PaymentService) RefundPayment(ctx context.Context, message []byte) error {
+	var cancelled events.OrderCancelled
+	if err := events.Decode(message, &cancelled); err != nil {
+		return retry.Permanent(err)
+	}
+	ctx = logging.With(ctx, "order_id", cancelled.OrderID)
+
+	result, err := s.payments.Get(cancelled.OrderID)
+	if errors.Is(err, repository.ErrNotFound) {
+		slog.InfoContext(ctx, "Order cancelled before payment")
+		return s.payments.Save(&events.PaymentResult{
+			OrderID:       cancelled.OrderID,
+			CustomerID:    cancelled.CustomerID,
+			RestaurantID:  cancelled.RestaurantID,
+			TotalAmount:   cancelled.TotalAmount,
+			PaymentStatus: statusCancelled,
+			ProcessedAt:   time.Now(),
+		})
+	} else if err != nil {
+		return err
+	}
+
+	switch result.PaymentStatus {
+	case events.PaymentStatusCompleted:
+	case statusRefunded:
+		slog.InfoContext(ctx, "Order already refunded, re-publishing refund")
+		return s.publishRefund(ctx, result)
+	default:
+		slog.InfoContext(ctx, "Order cancelled with nothing to refund", "payment_status", result.PaymentStatus)
+		return nil
+	}
+
+	slog.InfoContext(ctx, "Refunding payment", "amount", result.TotalAmount.String(), "transaction_id", result.TransactionID)
+
+	gatewayCtx, cancel := context.WithTimeout(ctx, gatewayTimeout)
+	defer cancel()
+
+	// A refund the gateway rejects outright can never succeed, so it goes
+	// straight to the dead-letter topic for manual follow-up.
+	var gwErr *gateway.Error
+	if err := s.gateway.Refund(gatewayCtx, result.TransactionID, result.TotalAmount); errors.As(err, &gwErr) && !gwErr.Temporary {
+		return retry.Permanent(err)
+	} else if err != nil {
+		return err
+	}
+
+	// The record's event ID now belongs to the PaymentRefunded event, which
+	// is re-published if the order is cancelled again.
+	result.PaymentStatus = statusRefunded
+	result.ProcessedAt = time.Now()
+	result.EventID = correlation.NewID()
+	if err := s.payments.Save(result); err != nil {
 		return err
 	}
 
-	// Simulate payment processing
-	log.Printf("Processing payment for order %s with amount %.2f", order.ID, order.Amount)
+	return s.publishRefund(ctx, result)
+}
 
-	// Create payment event
-	paymentEvent := PaymentEvent{
-		OrderID: order.ID,
-		Status:  "completed",
-		Amount:  order.Amount,
+func (s *PaymentService) publishRefund(ctx context.Context, result *events.PaymentResult) error {
+	refund := &events.PaymentRefunded{
+		Meta:          events.Meta{EventID: result.EventID},
+		OrderID:       result.OrderID,
+		CustomerID:    result.CustomerID,
+		RestaurantID:  result.RestaurantID,
+		Amount:        result.TotalAmount,
+		TransactionID: result.TransactionID,
+		RefundedAt:    result.ProcessedAt,
 	}
 
-	// Publish payment event
-	eventJSON, err := json.Marshal(paymentEvent)
+	events.Correlate(ctx, refund)
+	eventJSON, err := events.Encode(refund)
+	if err != nil {
+		return err
+	}
+
+	return s.kafkaClient.PublishMessage(ctx, s.topic, refund.PartitionKey(), events.Headers(refund), eventJSON)
+}
+
+func (s *PaymentService) publishResult(ctx context.Context, result *events.PaymentResult) error {
+	events.Correlate(ctx, result)
+	eventJSON, err := events.Encode(result)
 	if err != nil {
 		return err
 	}
 
-	return s.kafkaClient.PublishMessage("payment-events", eventJSON)
+	return s.kafkaClient.PublishMessage(ctx, s.topic, result.PartitionKey(), events.Headers(result), eventJSON)
 }
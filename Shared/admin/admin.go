@@ -0,0 +1,54 @@
+// Package admin runs the small HTTP server that consumer-only services use
+// to expose metrics and health endpoints.
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/learning-kafka/Shared/health"
+	"github.com/learning-kafka/Shared/metrics"
+)
+
+// Server serves /metrics, /healthz and /readyz.
+type Server struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+// Listen binds addr before anything is served, so that a service whose admin
+// port is taken fails to start instead of running without metrics and
+// health checks.
+func Listen(addr string, checker *health.Checker) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("admin server: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/healthz", checker.LivenessHandler())
+	mux.Handle("/readyz", checker.ReadinessHandler())
+
+	return &Server{
+		server:   &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second},
+		listener: listener,
+	}, nil
+}
+
+// Serve serves requests until ctx is done.
+func (s *Server) Serve(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		s.server.Close()
+	}()
+
+	if err := s.server.Serve(s.listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
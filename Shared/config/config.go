@@ -0,0 +1,290 @@
+// Package config loads a service's typed configuration from a YAML file,
+// environment variables and command-line flags.
+//
+// A configuration is a struct whose fields hold their defaults. Each field
+// names its sources in struct tags: yaml for its key in the file, env for its
+// environment variable and flag for its command-line flag, with usage
+// describing it in --help. Fields tagged secret:"true" are redacted when the
+// configuration is printed. Nested structs are walked recursively.
+//
+// Supported field types are string, bool, int, time.Duration and slices of
+// strings or durations, which env and flags give as comma-separated lists.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the environment variable that, like the --config flag, gives
+// the path of the YAML configuration file.
+const FileEnv = "CONFIG_FILE"
+
+const redacted = "REDACTED"
+
+// Validator is implemented by configurations that check themselves once
+// loaded.
+type Validator interface {
+	Validate() error
+}
+
+// Load fills cfg, a pointer to a configuration struct holding its defaults,
+// from these sources, each overriding the ones before it:
+//
+//  1. the YAML file named by --config or CONFIG_FILE, if any
+//  2. environment variables
+//  3. command-line flags in args
+//
+// It then validates cfg if it implements Validator. With --print-config, Load
+// instead prints the effective configuration as YAML, with secrets redacted,
+// and exits. Like flag.ExitOnError, it also exits on --help and on flags it
+// cannot parse.
+func Load(name string, cfg any, args []string) error {
+	root := reflect.ValueOf(cfg)
+	if root.Kind() != reflect.Pointer || root.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: want a pointer to a struct, got %T", cfg)
+	}
+	fields := collect(root.Elem())
+
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	file := flags.String("config", os.Getenv(FileEnv), "path of the YAML configuration file (env "+FileEnv+")")
+	printConfig := flags.Bool("print-config", false, "print the effective configuration, with secrets redacted, and exit")
+	var overrides []func()
+	for _, f := range fields {
+		if f.flag != "" {
+			flags.Var(&fieldFlag{field: f, overrides: &overrides}, f.flag, f.describe())
+		}
+	}
+	flags.Parse(args)
+
+	if *file != "" {
+		if err := loadFile(*file, cfg); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if value := os.Getenv(f.env); value != "" {
+			if err := set(f.value, value); err != nil {
+				return fmt.Errorf("invalid %s: %w", f.env, err)
+			}
+		}
+	}
+
+	for _, override := range overrides {
+		override()
+	}
+
+	if *printConfig {
+		if err := Print(os.Stdout, cfg); err != nil {
+			return err
+		}
+		os.Exit(0)
+	}
+
+	if v, ok := cfg.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+func loadFile(path string, cfg any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// Print writes cfg to w as YAML, replacing the value of every non-empty
+// secret field with REDACTED.
+func Print(w io.Writer, cfg any) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node(reflect.Indirect(reflect.ValueOf(cfg)), false)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// field is a configurable leaf of a configuration struct.
+type field struct {
+	value  reflect.Value
+	env    string
+	flag   string
+	usage  string
+	secret bool
+}
+
+func (f field) describe() string {
+	usage := f.usage
+	if f.env != "" {
+		usage += " (env " + f.env + ")"
+	}
+	return usage
+}
+
+func collect(v reflect.Value) []field {
+	var fields []field
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		if sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, collect(v.Field(i))...)
+			continue
+		}
+		fields = append(fields, field{
+			value:  v.Field(i),
+			env:    sf.Tag.Get("env"),
+			flag:   sf.Tag.Get("flag"),
+			usage:  sf.Tag.Get("usage"),
+			secret: sf.Tag.Get("secret") == "true",
+		})
+	}
+	return fields
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses s into v according to v's type.
+func set(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := set(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// format is the inverse of set.
+func format(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = format(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// fieldFlag parses a flag when the command line is parsed, so that bad
+// values are reported at once, but only applies it once the file and
+// environment have been loaded.
+type fieldFlag struct {
+	field     field
+	overrides *[]func()
+}
+
+func (f *fieldFlag) String() string {
+	if f == nil || !f.field.value.IsValid() || f.field.secret {
+		return ""
+	}
+	return format(f.field.value)
+}
+
+func (f *fieldFlag) Set(s string) error {
+	parsed := reflect.New(f.field.value.Type()).Elem()
+	if err := set(parsed, s); err != nil {
+		return err
+	}
+	*f.overrides = append(*f.overrides, func() { f.field.value.Set(parsed) })
+	return nil
+}
+
+func (f *fieldFlag) IsBoolFlag() bool {
+	return f.field.value.IsValid() && f.field.value.Kind() == reflect.Bool
+}
+
+// node converts v to a YAML node keyed by its fields' yaml tags, in
+// declaration order.
+func node(v reflect.Value, secret bool) *yaml.Node {
+	switch {
+	case v.Kind() == reflect.Struct:
+		mapping := &yaml.Node{Kind: yaml.MappingNode}
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			key, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+			if key == "" {
+				key = strings.ToLower(sf.Name)
+			}
+			mapping.Content = append(mapping.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+				node(v.Field(i), sf.Tag.Get("secret") == "true"))
+		}
+		return mapping
+	case secret && !v.IsZero():
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: redacted}
+	case v.Kind() == reflect.Slice:
+		sequence := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for i := 0; i < v.Len(); i++ {
+			sequence.Content = append(sequence.Content, node(v.Index(i), false))
+		}
+		return sequence
+	case v.Kind() == reflect.String:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v.String()}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: format(v)}
+	}
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/learning-kafka/Shared/events"
+	"github.com/learning-kafka/Shared/partition"
+	"github.com/learning-kafka/Shared/retry"
+)
+
+// Kafka configures a service's Kafka clients.
+type Kafka struct {
+	Brokers         []string      `yaml:"brokers" env:"KAFKA_BROKERS" flag:"kafka-brokers" usage:"comma-separated host:port addresses of the bootstrap brokers"`
+	ConnectAttempts int           `yaml:"connect_attempts" env:"KAFKA_CONNECT_ATTEMPTS" flag:"kafka-connect-attempts" usage:"times the bootstrap brokers are tried at startup"`
+	ConnectBackoff  time.Duration `yaml:"connect_backoff" env:"KAFKA_CONNECT_BACKOFF" flag:"kafka-connect-backoff" usage:"wait between startup connection attempts"`
+	ClientID        string        `yaml:"client_id" env:"KAFKA_CLIENT_ID" flag:"kafka-client-id" usage:"client ID sent to the brokers"`
+	GroupID         string        `yaml:"group_id" env:"KAFKA_GROUP_ID" flag:"kafka-group-id" usage:"consumer group ID"`
+	RequiredAcks    string        `yaml:"required_acks" env:"KAFKA_REQUIRED_ACKS" flag:"kafka-required-acks" usage:"acknowledgements a produce waits for: all, leader or none"`
+	ProducerRetries int           `yaml:"producer_retries" env:"KAFKA_PRODUCER_RETRIES" flag:"kafka-producer-retries" usage:"times a failed produce is retried"`
+	Partitioner     string        `yaml:"partitioner" env:"KAFKA_PARTITIONER" flag:"kafka-partitioner" usage:"partitioner for message keys: hash, crc32 or reference"`
+	SASL            SASL          `yaml:"sasl"`
+	Topics          Topics        `yaml:"topics"`
+}
+
+// SASL holds the credentials for SASL/PLAIN authentication, which is used
+// when Username is set.
+type SASL struct {
+	Username string `yaml:"username" env:"KAFKA_SASL_USERNAME" flag:"kafka-sasl-username" usage:"SASL/PLAIN user name"`
+	Password string `yaml:"password" env:"KAFKA_SASL_PASSWORD" flag:"kafka-sasl-password" usage:"SASL/PLAIN password" secret:"true"`
+}
+
+// Topics names the topics the services exchange events on.
+type Topics struct {
+	OrderEvents   string `yaml:"order_events" env:"KAFKA_TOPIC_ORDER_EVENTS" flag:"kafka-topic-order-events" usage:"topic of order events"`
+	PaymentEvents string `yaml:"payment_events" env:"KAFKA_TOPIC_PAYMENT_EVENTS" flag:"kafka-topic-payment-events" usage:"topic of payment events"`
+}
+
+var requiredAcks = map[string]sarama.RequiredAcks{
+	"all":    sarama.WaitForAll,
+	"leader": sarama.WaitForLocal,
+	"none":   sarama.NoResponse,
+}
+
+// DefaultKafka returns the defaults for a service whose consumer group, and
+// client ID, is groupID.
+func DefaultKafka(groupID string) Kafka {
+	return Kafka{
+		Brokers:         []string{"localhost:9092"},
+		ConnectAttempts: 5,
+		ConnectBackoff:  2 * time.Second,
+		ClientID:        groupID,
+		GroupID:         groupID,
+		RequiredAcks:    "all",
+		ProducerRetries: 5,
+		Partitioner:     partition.DefaultPartitioner,
+		Topics: Topics{
+			OrderEvents:   events.TopicOrderEvents,
+			PaymentEvents: events.TopicPaymentEvents,
+		},
+	}
+}
+
+func (k Kafka) Validate() error {
+	var errs []error
+	if len(k.Brokers) == 0 {
+		errs = append(errs, errors.New("kafka.brokers is required"))
+	}
+	for _, broker := range k.Brokers {
+		if err := checkAddr(broker); err != nil {
+			errs = append(errs, fmt.Errorf("kafka.brokers %q: %w", broker, err))
+		}
+	}
+	if k.ConnectAttempts < 1 {
+		errs = append(errs, fmt.Errorf("kafka.connect_attempts %d: want at least 1", k.ConnectAttempts))
+	}
+	if k.ConnectBackoff < 0 {
+		errs = append(errs, fmt.Errorf("kafka.connect_backoff %s: must not be negative", k.ConnectBackoff))
+	}
+	if k.GroupID == "" {
+		errs = append(errs, errors.New("kafka.group_id is required"))
+	}
+	if _, ok := requiredAcks[k.RequiredAcks]; !ok {
+		errs = append(errs, fmt.Errorf("kafka.required_acks %q: want all, leader or none", k.RequiredAcks))
+	}
+	if k.ProducerRetries < 0 {
+		errs = append(errs, fmt.Errorf("kafka.producer_retries %d: must not be negative", k.ProducerRetries))
+	}
+	if _, err := partition.Partitioner(k.Partitioner); err != nil {
+		errs = append(errs, fmt.Errorf("kafka.partitioner: %w", err))
+	}
+	if k.SASL.Username != "" && k.SASL.Password == "" {
+		errs = append(errs, errors.New("kafka.sasl.password is required with kafka.sasl.username"))
+	}
+	if k.Topics.OrderEvents == "" || k.Topics.PaymentEvents == "" {
+		errs = append(errs, errors.New("kafka.topics must all be set"))
+	}
+	return errors.Join(errs...)
+}
+
+// checkAddr reports whether addr is a host:port broker address.
+func checkAddr(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return errors.New("want host:port")
+	}
+	if host == "" {
+		return errors.New("missing host")
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// Sarama returns a sarama configuration carrying k's client, producer and
+// authentication settings. Callers add the settings specific to their
+// clients, such as the consumer group's rebalance strategy.
+func (k Kafka) Sarama() (*sarama.Config, error) {
+	config := sarama.NewConfig()
+	config.ClientID = k.ClientID
+	config.Producer.RequiredAcks = requiredAcks[k.RequiredAcks]
+	config.Producer.Retry.Max = k.ProducerRetries
+	config.Producer.Return.Successes = true
+
+	partitioner, err := partition.Partitioner(k.Partitioner)
+	if err != nil {
+		return nil, err
+	}
+	config.Producer.Partitioner = partitioner
+
+	if k.SASL.Username != "" {
+		config.Net.SASL.Enable = true
+		config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		config.Net.SASL.User = k.SASL.Username
+		config.Net.SASL.Password = k.SASL.Password
+	}
+	return config, nil
+}
+
+// Connect returns a client bootstrapped from k.Brokers with config, which
+// callers build from Sarama. sarama itself tries each bootstrap broker in
+// turn; Connect retries the whole list up to ConnectAttempts times,
+// ConnectBackoff apart, so that a service may start before its brokers do.
+// If every attempt fails, the error gives the reason each broker could not be
+// reached.
+func (k Kafka) Connect(config *sarama.Config) (sarama.Client, error) {
+	for attempt := 1; ; attempt++ {
+		client, err := sarama.NewClient(k.Brokers, config)
+		if err == nil {
+			return client, nil
+		}
+		var configErr sarama.ConfigurationError
+		if errors.As(err, &configErr) {
+			return nil, err
+		}
+		if attempt >= k.ConnectAttempts {
+			// sarama's own error already lists the dial errors, but not
+			// always which broker each belongs to.
+			if errors.Is(err, sarama.ErrOutOfBrokers) {
+				err = sarama.ErrOutOfBrokers
+			}
+			return nil, fmt.Errorf("no Kafka broker reachable after %d attempts (%s): %w",
+				attempt, strings.Join(diagnose(k.Brokers, config), "; "), err)
+		}
+		slog.Warn("Kafka brokers unreachable, retrying",
+			"brokers", k.Brokers, "attempt", attempt, "backoff", k.ConnectBackoff, "error", err)
+		time.Sleep(k.ConnectBackoff)
+	}
+}
+
+// diagnose connects to each of brokers on its own and describes the outcome,
+// since sarama only reports that it ran out of brokers to try.
+func diagnose(brokers []string, config *sarama.Config) []string {
+	results := make([]string, len(brokers))
+	for i, addr := range brokers {
+		broker := sarama.NewBroker(addr)
+		err := broker.Open(config)
+		if err == nil {
+			_, err = broker.Connected()
+		}
+		broker.Close()
+		if err != nil {
+			results[i] = fmt.Sprintf("%s: %v", addr, err)
+		} else {
+			results[i] = addr + ": connected, but could not fetch metadata"
+		}
+	}
+	return results
+}
+
+// Retry configures the retry topics a consumer routes failed messages
+// through.
+type Retry struct {
+	Delays      []time.Duration `yaml:"delays" env:"RETRY_DELAYS" flag:"retry-delays" usage:"comma-separated delays of the retry tiers"`
+	MaxAttempts int             `yaml:"max_attempts" env:"RETRY_MAX_ATTEMPTS" flag:"retry-max-attempts" usage:"attempts before a message is dead-lettered"`
+}
+
+func DefaultRetry() Retry {
+	policy := retry.DefaultPolicy()
+	return Retry{
+		Delays:      policy.Delays,
+		MaxAttempts: policy.MaxAttempts,
+	}
+}
+
+func (r Retry) Validate() error {
+	var errs []error
+	if len(r.Delays) == 0 {
+		errs = append(errs, errors.New("retry.delays must list at least one delay"))
+	}
+	for _, delay := range r.Delays {
+		switch {
+		case delay <= 0:
+			errs = append(errs, fmt.Errorf("retry.delays: %s is not positive", delay))
+		case delay%time.Second != 0:
+			errs = append(errs, fmt.Errorf("retry.delays: %s is not a whole number of seconds", delay))
+		}
+	}
+	if r.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("retry.max_attempts %d: want at least 1", r.MaxAttempts))
+	}
+	return errors.Join(errs...)
+}
+
+// Policy returns the retry policy r describes.
+func (r Retry) Policy() retry.Policy {
+	return retry.Policy{
+		Delays:      r.Delays,
+		MaxAttempts: r.MaxAttempts,
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		delays []time.Duration
+		ok     bool
+	}{
+		{name: "default", delays: DefaultRetry().Delays, ok: true},
+		{name: "whole seconds", delays: []time.Duration{time.Second, 90 * time.Second, 2 * time.Hour}, ok: true},
+		{name: "empty", delays: nil},
+		{name: "zero", delays: []time.Duration{0}},
+		{name: "negative", delays: []time.Duration{-5 * time.Second}},
+		{name: "fraction of a second", delays: []time.Duration{time.Second, 1500 * time.Millisecond}},
+		{name: "under a second", delays: []time.Duration{500 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Retry{Delays: tt.delays, MaxAttempts: 4}.Validate()
+			if tt.ok && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if !tt.ok && err == nil {
+				t.Errorf("Validate() = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Package correlation ties together everything that happens because of one
+// request. The request's ID becomes the correlation ID of every event it
+// leads to, directly or through other services, and each event records the
+// ID of the event or request that caused it.
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+)
+
+// RequestIDHeader is the HTTP header a request ID is accepted from and
+// echoed in.
+const RequestIDHeader = "X-Request-ID"
+
+// Kafka headers carrying an event's IDs. They duplicate the event_id,
+// correlation_id and causation_id fields of the event envelope so that they
+// can be read without decoding the payload.
+const (
+	HeaderEventID       = "x-event-id"
+	HeaderCorrelationID = "x-correlation-id"
+	HeaderCausationID   = "x-causation-id"
+)
+
+// maxRequestIDLen bounds the request IDs accepted from clients.
+const maxRequestIDLen = 128
+
+type contextKey struct{}
+
+type ids struct {
+	correlationID string
+	causationID   string
+}
+
+// NewID returns a random UUID.
+func NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// ValidRequestID reports whether id may be used as a request ID: it must be
+// short and consist of printable ASCII.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// With returns ctx carrying the correlation ID of the work it is part of and
+// the ID of the request or event that caused it.
+func With(ctx context.Context, correlationID, causationID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, ids{correlationID: correlationID, causationID: causationID})
+}
+
+// IDs returns the correlation and causation IDs carried by ctx, which are
+// empty if it carries none.
+func IDs(ctx context.Context) (correlationID, causationID string) {
+	v, _ := ctx.Value(contextKey{}).(ids)
+	return v.correlationID, v.causationID
+}
+
+// FromMessage returns ctx carrying the IDs for handling msg: its correlation
+// ID, and its event ID as the cause of anything published while handling it.
+// The IDs are read from msg's headers, or from the event envelope for
+// messages published without them. A message with no IDs at all starts a new
+// correlation.
+func FromMessage(ctx context.Context, msg *sarama.ConsumerMessage) context.Context {
+	eventID := header(msg, HeaderEventID)
+	correlationID := header(msg, HeaderCorrelationID)
+
+	if eventID == "" || correlationID == "" {
+		var envelope struct {
+			EventID       string `json:"event_id"`
+			CorrelationID string `json:"correlation_id"`
+		}
+		if err := json.Unmarshal(msg.Value, &envelope); err == nil {
+			if eventID == "" {
+				eventID = envelope.EventID
+			}
+			if correlationID == "" {
+				correlationID = envelope.CorrelationID
+			}
+		}
+	}
+
+	if correlationID == "" {
+		correlationID = eventID
+	}
+	if correlationID == "" {
+		correlationID = NewID()
+	}
+	return With(ctx, correlationID, eventID)
+}
+
+func header(msg *sarama.ConsumerMessage, key string) string {
+	for _, h := range msg.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
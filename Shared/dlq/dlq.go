@@ -0,0 +1,112 @@
+// Package dlq moves messages that a consumer could not process to a
+// dead-letter topic, so that one bad message never blocks its partition.
+package dlq
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/learning-kafka/Shared/metrics"
+)
+
+// Headers added to every dead-lettered message. The original message's own
+// headers are preserved alongside them.
+const (
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderError             = "x-error"
+	HeaderAttempts          = "x-attempts"
+	HeaderService           = "x-service"
+	HeaderFailedAt          = "x-failed-at"
+)
+
+// Topic returns the dead-letter topic for topic.
+func Topic(topic string) string {
+	return topic + ".dlq"
+}
+
+type Publisher struct {
+	producer sarama.SyncProducer
+	service  string
+}
+
+func NewPublisher(producer sarama.SyncProducer, service string) *Publisher {
+	return &Publisher{
+		producer: producer,
+		service:  service,
+	}
+}
+
+// Publish sends msg, unchanged, to the dead-letter topic of the topic it was
+// consumed from, annotated with where it came from and why it failed. The
+// caller should mark msg as consumed only if Publish succeeds.
+func (p *Publisher) Publish(msg *sarama.ConsumerMessage, cause error) error {
+	// A message coming from a retry topic already records where it was
+	// originally consumed from.
+	topic := msg.Topic
+	partition := strconv.Itoa(int(msg.Partition))
+	offset := strconv.FormatInt(msg.Offset, 10)
+	if original := Header(msg, HeaderOriginalTopic); original != "" {
+		topic = original
+		partition = Header(msg, HeaderOriginalPartition)
+		offset = Header(msg, HeaderOriginalOffset)
+	}
+
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+7)
+	for _, h := range msg.Headers {
+		switch string(h.Key) {
+		case HeaderOriginalTopic, HeaderOriginalPartition, HeaderOriginalOffset,
+			HeaderError, HeaderAttempts, HeaderService, HeaderFailedAt:
+			continue
+		}
+		headers = append(headers, *h)
+	}
+	headers = append(headers,
+		header(HeaderOriginalTopic, topic),
+		header(HeaderOriginalPartition, partition),
+		header(HeaderOriginalOffset, offset),
+		header(HeaderError, cause.Error()),
+		header(HeaderAttempts, strconv.Itoa(Attempts(msg))),
+		header(HeaderService, p.service),
+		header(HeaderFailedAt, time.Now().UTC().Format(time.RFC3339)),
+	)
+
+	_, _, err := p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic:   Topic(topic),
+		Key:     sarama.ByteEncoder(msg.Key),
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.DeadLettered(p.service, topic)
+	return nil
+}
+
+// Attempts returns how many times msg has been processed, counting this
+// attempt.
+func Attempts(msg *sarama.ConsumerMessage) int {
+	n, err := strconv.Atoi(Header(msg, HeaderAttempts))
+	if err != nil {
+		return 1
+	}
+	return n + 1
+}
+
+// Header returns the value of the named header on msg, or "" if it is absent.
+func Header(msg *sarama.ConsumerMessage, key string) string {
+	for _, h := range msg.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func header(key, value string) sarama.RecordHeader {
+	return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
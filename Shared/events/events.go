@@ -0,0 +1,117 @@
+// Package events defines the Kafka event contract shared by the Orders,
+// Payments and Notifications services.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/learning-kafka/Shared/correlation"
+)
+
+// SchemaVersion is the version of the event contract written by this package.
+// Bump it whenever a field is removed or its meaning changes.
+const SchemaVersion = 1
+
+const (
+	TopicOrderEvents   = "order-events"
+	TopicPaymentEvents = "payment-events"
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported event schema version")
+	ErrUnexpectedType     = errors.New("unexpected event type")
+)
+
+// Meta is embedded in every event and identifies its type and schema version.
+// EventID is unique to each published event. CorrelationID is shared by every
+// event that follows from the same request, and CausationID is the ID of the
+// event or request that directly caused this one.
+type Meta struct {
+	EventType     string `json:"event_type"`
+	SchemaVersion int    `json:"schema_version"`
+	EventID       string `json:"event_id,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	CausationID   string `json:"causation_id,omitempty"`
+}
+
+func (m *Meta) meta() *Meta { return m }
+
+// Event is implemented by every type in this package. PartitionKey is the
+// Kafka message key the event must be produced with; events about the same
+// entity return the same key so that they land on the same partition and are
+// consumed in order.
+type Event interface {
+	Type() string
+	PartitionKey() string
+	meta() *Meta
+}
+
+// Encode stamps the event with its type, the current schema version and, if
+// it has none yet, a new event ID, and marshals it to JSON.
+func Encode(e Event) ([]byte, error) {
+	m := e.meta()
+	m.EventType = e.Type()
+	m.SchemaVersion = SchemaVersion
+	if m.EventID == "" {
+		m.EventID = correlation.NewID()
+	}
+	return json.Marshal(e)
+}
+
+// Correlate stamps the event with the correlation and causation IDs carried
+// by ctx.
+func Correlate(ctx context.Context, e Event) {
+	m := e.meta()
+	m.CorrelationID, m.CausationID = correlation.IDs(ctx)
+}
+
+// Headers returns the Kafka headers carrying the IDs of an encoded event.
+func Headers(e Event) map[string]string {
+	m := e.meta()
+	headers := map[string]string{correlation.HeaderEventID: m.EventID}
+	if m.CorrelationID != "" {
+		headers[correlation.HeaderCorrelationID] = m.CorrelationID
+	}
+	if m.CausationID != "" {
+		headers[correlation.HeaderCausationID] = m.CausationID
+	}
+	return headers
+}
+
+// Peek returns the metadata of an encoded event without decoding its payload.
+func Peek(data []byte) (Meta, error) {
+	var m Meta
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Meta{}, err
+	}
+	return m, nil
+}
+
+// Decode unmarshals data into e after checking that its type and schema
+// version are understood by this build. Messages written before the contract
+// was versioned carry neither field and are accepted as version 1.
+func Decode(data []byte, e Event) error {
+	m, err := Peek(data)
+	if err != nil {
+		return err
+	}
+	if m.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, m.SchemaVersion)
+	}
+	if m.EventType != "" && m.EventType != e.Type() {
+		return fmt.Errorf("%w: got %q, want %q", ErrUnexpectedType, m.EventType, e.Type())
+	}
+	if err := json.Unmarshal(data, e); err != nil {
+		return err
+	}
+
+	meta := e.meta()
+	meta.EventType = e.Type()
+	if meta.SchemaVersion == 0 {
+		meta.SchemaVersion = 1
+	}
+	return nil
+}
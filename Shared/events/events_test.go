@@ -0,0 +1,155 @@
+package events
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/learning-kafka/Shared/money"
+)
+
+func TestDecodeUnversionedAsV1(t *testing.T) {
+	legacy := `{"order_id":7,"customer_id":3,"restaurant_id":5,"total_amount":12.5,"status":"PENDING","items":[{"item_id":1,"quantity":2,"price":6.25}]}`
+
+	var event OrderCreated
+	if err := Decode([]byte(legacy), &event); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if event.SchemaVersion != 1 || event.EventType != TypeOrderCreated {
+		t.Errorf("meta = %+v, want version 1 of %s", event.Meta, TypeOrderCreated)
+	}
+	if want := money.New(1250, money.DefaultCurrency); event.TotalAmount != want {
+		t.Errorf("TotalAmount = %v, want %v", event.TotalAmount, want)
+	}
+	if want := money.New(625, money.DefaultCurrency); event.Items[0].Price != want {
+		t.Errorf("Items[0].Price = %v, want %v", event.Items[0].Price, want)
+	}
+}
+
+func TestDecodeRejectsNewerVersion(t *testing.T) {
+	newer := `{"event_type":"OrderCreated","schema_version":2,"order_id":7}`
+
+	var event OrderCreated
+	if err := Decode([]byte(newer), &event); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Decode = %v, want %v", err, ErrUnsupportedVersion)
+	}
+}
+
+func TestDecodeRejectsWrongType(t *testing.T) {
+	data, err := Encode(&PaymentResult{OrderID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var event OrderCreated
+	if err := Decode(data, &event); !errors.Is(err, ErrUnexpectedType) {
+		t.Errorf("Decode = %v, want %v", err, ErrUnexpectedType)
+	}
+}
+
+func TestLegacyFloatAmountRoundTrips(t *testing.T) {
+	legacy := `{"order_id":7,"total_amount":21.000000000000004,"payment_status":"COMPLETED"}`
+
+	var event PaymentResult
+	if err := Decode([]byte(legacy), &event); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if want := money.New(2100, money.DefaultCurrency); event.TotalAmount != want {
+		t.Fatalf("TotalAmount = %v, want %v", event.TotalAmount, want)
+	}
+
+	data, err := Encode(&event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"total_amount":21.00`) {
+		t.Errorf("Encode = %s, want total_amount 21.00", data)
+	}
+
+	var again PaymentResult
+	if err := Decode(data, &again); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if again.TotalAmount != event.TotalAmount {
+		t.Errorf("round trip: TotalAmount = %v, want %v", again.TotalAmount, event.TotalAmount)
+	}
+}
+
+func TestEncodeDecodeLossless(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	meta := Meta{CorrelationID: "correlation", CausationID: "causation"}
+
+	tests := []struct {
+		event Event
+		into  Event
+	}{
+		{
+			event: &OrderCreated{
+				Meta:         meta,
+				OrderID:      7,
+				CustomerID:   3,
+				RestaurantID: 5,
+				OrderDate:    at,
+				TotalAmount:  money.New(12346, "KWD"),
+				Status:       "PENDING",
+				Items: []OrderItem{
+					{OrderItemID: 1, ItemID: 10, Quantity: 2, Price: money.New(6173, "KWD")},
+				},
+			},
+			into: &OrderCreated{},
+		},
+		{
+			event: &OrderCancelled{
+				Meta:         meta,
+				OrderID:      7,
+				CustomerID:   3,
+				RestaurantID: 5,
+				TotalAmount:  money.New(1250, "EUR"),
+				CancelledAt:  at,
+			},
+			into: &OrderCancelled{},
+		},
+		{
+			event: &PaymentResult{
+				Meta:          meta,
+				OrderID:       7,
+				CustomerID:    3,
+				RestaurantID:  5,
+				TotalAmount:   money.New(1250, "JPY"),
+				PaymentStatus: PaymentStatusFailed,
+				ProcessedAt:   at,
+				TransactionID: "txn-1",
+				FailureReason: "card_declined",
+			},
+			into: &PaymentResult{},
+		},
+		{
+			event: &PaymentRefunded{
+				Meta:          meta,
+				OrderID:       7,
+				CustomerID:    3,
+				RestaurantID:  5,
+				Amount:        money.New(1250, money.DefaultCurrency),
+				TransactionID: "txn-1",
+				RefundedAt:    at,
+			},
+			into: &PaymentRefunded{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event.Type(), func(t *testing.T) {
+			data, err := Encode(tt.event)
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			if err := Decode(data, tt.into); err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !reflect.DeepEqual(tt.into, tt.event) {
+				t.Errorf("round trip = %+v, want %+v", tt.into, tt.event)
+			}
+		})
+	}
+}
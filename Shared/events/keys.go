@@ -0,0 +1,9 @@
+package events
+
+import "strconv"
+
+// OrderKey is the partition key of every event concerning a single order, so
+// that the order's events are never reordered.
+func OrderKey(orderID int) string {
+	return "order-" + strconv.Itoa(orderID)
+}
@@ -0,0 +1,102 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/learning-kafka/Shared/money"
+)
+
+const (
+	TypeOrderCreated   = "OrderCreated"
+	TypeOrderCancelled = "OrderCancelled"
+)
+
+type OrderItem struct {
+	OrderItemID int         `json:"order_item_id"`
+	ItemID      int         `json:"item_id"`
+	Quantity    int         `json:"quantity"`
+	Price       money.Money `json:"price"`
+}
+
+// OrderCreated is published to order-events when an order is accepted.
+type OrderCreated struct {
+	Meta
+	OrderID      int         `json:"order_id"`
+	CustomerID   int         `json:"customer_id"`
+	RestaurantID int         `json:"restaurant_id"`
+	OrderDate    time.Time   `json:"order_date"`
+	TotalAmount  money.Money `json:"total_amount"`
+	Status       string      `json:"status"`
+	Items        []OrderItem `json:"items"`
+}
+
+func (*OrderCreated) Type() string { return TypeOrderCreated }
+
+func (e *OrderCreated) PartitionKey() string { return OrderKey(e.OrderID) }
+
+// OrderCancelled is published to order-events when a customer cancels an
+// order. Any payment already taken for the order is refunded.
+type OrderCancelled struct {
+	Meta
+	OrderID      int         `json:"order_id"`
+	CustomerID   int         `json:"customer_id"`
+	RestaurantID int         `json:"restaurant_id"`
+	TotalAmount  money.Money `json:"total_amount"`
+	CancelledAt  time.Time   `json:"cancelled_at"`
+}
+
+func (*OrderCancelled) Type() string { return TypeOrderCancelled }
+
+func (e *OrderCancelled) PartitionKey() string { return OrderKey(e.OrderID) }
+
+// The JSON methods below encode every amount as a bare number and record
+// their shared currency in a "currency" field.
+
+type orderCreated OrderCreated
+
+func (e OrderCreated) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		orderCreated
+		Currency string `json:"currency"`
+	}{orderCreated(e), money.CurrencyOf(e.TotalAmount)})
+}
+
+func (e *OrderCreated) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*orderCreated
+		Currency string `json:"currency"`
+	}{orderCreated: (*orderCreated)(e)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	amounts := []*money.Money{&e.TotalAmount}
+	for i := range e.Items {
+		amounts = append(amounts, &e.Items[i].Price)
+	}
+	money.Stamp(aux.Currency, amounts...)
+	return nil
+}
+
+type orderCancelled OrderCancelled
+
+func (e OrderCancelled) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		orderCancelled
+		Currency string `json:"currency"`
+	}{orderCancelled(e), money.CurrencyOf(e.TotalAmount)})
+}
+
+func (e *OrderCancelled) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*orderCancelled
+		Currency string `json:"currency"`
+	}{orderCancelled: (*orderCancelled)(e)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	money.Stamp(aux.Currency, &e.TotalAmount)
+	return nil
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/learning-kafka/Shared/money"
+)
+
+const (
+	TypePaymentResult   = "PaymentResult"
+	TypePaymentRefunded = "PaymentRefunded"
+)
+
+const (
+	PaymentStatusCompleted = "COMPLETED"
+	PaymentStatusFailed    = "FAILED"
+)
+
+// PaymentResult is published to payment-events once an order's payment has
+// been processed.
+type PaymentResult struct {
+	Meta
+	OrderID       int         `json:"order_id"`
+	CustomerID    int         `json:"customer_id"`
+	RestaurantID  int         `json:"restaurant_id"`
+	TotalAmount   money.Money `json:"total_amount"`
+	PaymentStatus string      `json:"payment_status"`
+	ProcessedAt   time.Time   `json:"processed_at"`
+	TransactionID string      `json:"transaction_id"`
+	FailureReason string      `json:"failure_reason,omitempty"`
+}
+
+func (*PaymentResult) Type() string { return TypePaymentResult }
+
+func (e *PaymentResult) PartitionKey() string { return OrderKey(e.OrderID) }
+
+// PaymentRefunded is published to payment-events once the payment for a
+// cancelled order has been returned to the customer.
+type PaymentRefunded struct {
+	Meta
+	OrderID       int         `json:"order_id"`
+	CustomerID    int         `json:"customer_id"`
+	RestaurantID  int         `json:"restaurant_id"`
+	Amount        money.Money `json:"amount"`
+	TransactionID string      `json:"transaction_id"`
+	RefundedAt    time.Time   `json:"refunded_at"`
+}
+
+func (*PaymentRefunded) Type() string { return TypePaymentRefunded }
+
+func (e *PaymentRefunded) PartitionKey() string { return OrderKey(e.OrderID) }
+
+type paymentResult PaymentResult
+
+func (e PaymentResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		paymentResult
+		Currency string `json:"currency"`
+	}{paymentResult(e), money.CurrencyOf(e.TotalAmount)})
+}
+
+func (e *PaymentResult) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*paymentResult
+		Currency string `json:"currency"`
+	}{paymentResult: (*paymentResult)(e)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	money.Stamp(aux.Currency, &e.TotalAmount)
+	return nil
+}
+
+type paymentRefunded PaymentRefunded
+
+func (e PaymentRefunded) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		paymentRefunded
+		Currency string `json:"currency"`
+	}{paymentRefunded(e), money.CurrencyOf(e.Amount)})
+}
+
+func (e *PaymentRefunded) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*paymentRefunded
+		Currency string `json:"currency"`
+	}{paymentRefunded: (*paymentRefunded)(e)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	money.Stamp(aux.Currency, &e.Amount)
+	return nil
+}
@@ -0,0 +1,164 @@
+// Package health reports whether a service is alive and whether it is ready
+// to do its work, for container healthchecks and orchestrators.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// checkTimeout bounds how long a readiness check may take, so that a probe
+// against an unreachable broker fails instead of hanging.
+const checkTimeout = 2 * time.Second
+
+var (
+	ErrClientClosed = errors.New("kafka client is closed")
+	ErrNotJoined    = errors.New("not a member of the consumer group")
+)
+
+// Check reports why a dependency is not usable, or nil if it is.
+type Check func(ctx context.Context) error
+
+// Checker runs a service's readiness checks.
+type Checker struct {
+	mu     sync.Mutex
+	names  []string
+	checks map[string]Check
+}
+
+func NewChecker() *Checker {
+	return &Checker{checks: make(map[string]Check)}
+}
+
+// Add registers a readiness check under name, replacing any check already
+// registered under it.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Ready runs every check concurrently and reports whether they all passed,
+// along with each check's result.
+func (c *Checker) Ready(ctx context.Context) (bool, map[string]string) {
+	c.mu.Lock()
+	names := append([]string(nil), c.names...)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = c.checks[name]
+	}
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			errs[i] = check(ctx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	ready := true
+	results := make(map[string]string, len(names))
+	for i, name := range names {
+		results[name] = "ok"
+		if errs[i] != nil {
+			ready = false
+			results[name] = errs[i].Error()
+		}
+	}
+	return ready, results
+}
+
+// LivenessHandler answers 200 for as long as the process can serve requests.
+// It does not look at dependencies, so an orchestrator does not restart a
+// service just because Kafka is down.
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+	})
+}
+
+// ReadinessHandler answers 200 if every check passes and 503 otherwise, with
+// the result of each check in the body.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ready, results := c.Ready(r.Context())
+
+		status, code := "ready", http.StatusOK
+		if !ready {
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, map[string]interface{}{"status": status, "checks": results})
+	})
+}
+
+// Brokers returns a check that passes if client can fetch cluster metadata
+// from one of its brokers.
+func Brokers(client sarama.Client) Check {
+	return func(ctx context.Context) error {
+		if client.Closed() {
+			return ErrClientClosed
+		}
+
+		// RefreshMetadata cannot be cancelled; if it outlives the check it
+		// finishes in the background.
+		done := make(chan error, 1)
+		go func() { done <- client.RefreshMetadata() }()
+
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Membership tracks whether a consumer group handler currently holds a
+// session, that is whether the consumer has joined its group and been
+// assigned partitions.
+type Membership struct {
+	joined atomic.Bool
+}
+
+// Joined is called from the handler's Setup.
+func (m *Membership) Joined() {
+	m.joined.Store(true)
+}
+
+// Left is called from the handler's Cleanup.
+func (m *Membership) Left() {
+	m.joined.Store(false)
+}
+
+// Check fails while the consumer is not in a group session, such as before
+// it first joins and during a rebalance.
+func (m *Membership) Check(context.Context) error {
+	if !m.joined.Load() {
+		return ErrNotJoined
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
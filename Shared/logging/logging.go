@@ -0,0 +1,103 @@
+// Package logging sets up the structured logger shared by the services. Every
+// record carries the service name, and records logged with a context also
+// carry the correlation IDs and any fields added to it with With, such as the
+// topic, partition and offset of the message being handled.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/Shopify/sarama"
+	"github.com/learning-kafka/Shared/correlation"
+)
+
+// Init installs the default slog logger for service, which also receives
+// everything written through the standard log package. LOG_LEVEL sets the
+// minimum level (debug, info, the default, warn or error) and LOG_FORMAT the
+// output: "json", the default, or "text".
+func Init(service string) error {
+	level := slog.LevelInfo
+	if env := os.Getenv("LOG_LEVEL"); env != "" {
+		if err := level.UnmarshalText([]byte(env)); err != nil {
+			return fmt.Errorf("invalid LOG_LEVEL: %q", env)
+		}
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch format := strings.ToLower(os.Getenv("LOG_FORMAT")); format {
+	case "", "json":
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, options)
+	default:
+		return fmt.Errorf("invalid LOG_FORMAT: %q", format)
+	}
+
+	handler = handler.WithAttrs([]slog.Attr{slog.String("service", service)})
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return nil
+}
+
+// Fatal logs msg at error level and exits, for errors a service cannot start
+// or keep running after.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+type contextKey struct{}
+
+// With returns ctx carrying args, given as for slog.Logger.Log, in addition
+// to any fields it already carries. Every record logged with the returned
+// context includes them.
+func With(ctx context.Context, args ...any) context.Context {
+	attrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	record := slog.Record{}
+	record.Add(args...)
+	merged := make([]slog.Attr, len(attrs), len(attrs)+record.NumAttrs())
+	copy(merged, attrs)
+	record.Attrs(func(a slog.Attr) bool {
+		merged = append(merged, a)
+		return true
+	})
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+// WithMessage returns ctx carrying the topic, partition and offset of msg.
+func WithMessage(ctx context.Context, msg *sarama.ConsumerMessage) context.Context {
+	return With(ctx, "topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset)
+}
+
+// contextHandler adds the fields carried by a record's context to it.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		return h.Handler.Handle(ctx, r)
+	}
+	if correlationID, causationID := correlation.IDs(ctx); correlationID != "" {
+		r.AddAttrs(slog.String("correlation_id", correlationID))
+		if causationID != "" {
+			r.AddAttrs(slog.String("causation_id", causationID))
+		}
+	}
+	if attrs, ok := ctx.Value(contextKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,123 @@
+// Package metrics defines the Prometheus metrics shared by the services and
+// the helpers that record them. Every metric is registered with the default
+// registry, which Handler serves together with the Go runtime and process
+// metrics.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	produceDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_produce_duration_seconds",
+		Help:    "Time taken to produce a message and have it acknowledged, by topic.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	produceErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_produce_errors_total",
+		Help: "Messages that could not be produced, by topic.",
+	}, []string{"topic"})
+
+	messagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumed_messages_total",
+		Help: "Messages consumed, by consumer group and topic.",
+	}, []string{"group", "topic"})
+
+	handlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_handler_duration_seconds",
+		Help:    "Time taken to handle a consumed message, by consumer group, topic and outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"group", "topic", "outcome"})
+
+	consumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_lag",
+		Help: "Messages in a claimed partition that the consumer group has not read yet.",
+	}, []string{"group", "topic", "partition"})
+
+	retried = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_retried_messages_total",
+		Help: "Failed messages sent to a retry topic, by service and original topic.",
+	}, []string{"service", "topic"})
+
+	deadLettered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_dead_lettered_messages_total",
+		Help: "Messages sent to a dead-letter topic, by service and original topic.",
+	}, []string{"service", "topic"})
+
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to serve an HTTP request, by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// Handler serves every registered metric in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveMessage records that group handled msg, which took since start and
+// failed if err is not nil.
+func ObserveMessage(group string, msg *sarama.ConsumerMessage, start time.Time, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+
+	messagesConsumed.WithLabelValues(group, msg.Topic).Inc()
+	handlerDuration.WithLabelValues(group, msg.Topic, outcome).Observe(time.Since(start).Seconds())
+}
+
+// ObserveLag records how far group is behind the end of claim's partition
+// once it has read msg.
+func ObserveLag(group string, claim sarama.ConsumerGroupClaim, msg *sarama.ConsumerMessage) {
+	lag := claim.HighWaterMarkOffset() - msg.Offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	consumerLag.WithLabelValues(group, claim.Topic(), partitionLabel(claim.Partition())).Set(float64(lag))
+}
+
+// ForgetClaim stops reporting the lag of claim's partition, which is called
+// when the partition is revoked so that its last value does not linger on a
+// consumer that no longer owns it.
+func ForgetClaim(group string, claim sarama.ConsumerGroupClaim) {
+	consumerLag.DeleteLabelValues(group, claim.Topic(), partitionLabel(claim.Partition()))
+}
+
+// Retried records that service sent a message consumed from topic to a
+// retry topic.
+func Retried(service, topic string) {
+	retried.WithLabelValues(service, topic).Inc()
+}
+
+// DeadLettered records that service sent a message consumed from topic to the
+// dead-letter topic.
+func DeadLettered(service, topic string) {
+	deadLettered.WithLabelValues(service, topic).Inc()
+}
+
+// ObserveRequest records an HTTP request for route, the pattern it matched
+// such as /api/v1/orders/:id, that was answered with status after start.
+func ObserveRequest(method, route string, status int, start time.Time) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+}
+
+func partitionLabel(partition int32) string {
+	return strconv.Itoa(int(partition))
+}
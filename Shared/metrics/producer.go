@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// InstrumentProducer returns producer with every send recorded in the
+// produce latency and error metrics of the message's topic.
+func InstrumentProducer(producer sarama.SyncProducer) sarama.SyncProducer {
+	return &instrumentedProducer{SyncProducer: producer}
+}
+
+type instrumentedProducer struct {
+	sarama.SyncProducer
+}
+
+func (p *instrumentedProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	start := time.Now()
+	partition, offset, err := p.SyncProducer.SendMessage(msg)
+	observeProduce(msg.Topic, start, err)
+	return partition, offset, err
+}
+
+func (p *instrumentedProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	start := time.Now()
+	err := p.SyncProducer.SendMessages(msgs)
+
+	failed := make(map[*sarama.ProducerMessage]error)
+	var produceErrs sarama.ProducerErrors
+	if errors.As(err, &produceErrs) {
+		for _, produceErr := range produceErrs {
+			failed[produceErr.Msg] = produceErr.Err
+		}
+	}
+	for _, msg := range msgs {
+		observeProduce(msg.Topic, start, failed[msg])
+	}
+	return err
+}
+
+func observeProduce(topic string, start time.Time, err error) {
+	if err != nil {
+		produceErrors.WithLabelValues(topic).Inc()
+		return
+	}
+	produceDuration.WithLabelValues(topic).Observe(time.Since(start).Seconds())
+}
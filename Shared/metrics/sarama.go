@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Shopify/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// saramaQuantiles are the quantiles exported for sarama's histograms.
+var saramaQuantiles = []float64{0.5, 0.75, 0.95, 0.99}
+
+// saramaLabels pull the broker, topic, API key and consumer group that sarama
+// embeds in its metric names out into labels. Per-broker and per-topic
+// metrics keep a suffix because sarama also records an unlabelled total
+// under the same base name.
+var saramaLabels = []struct {
+	pattern *regexp.Regexp
+	label   string
+	suffix  string
+}{
+	{regexp.MustCompile(`^(.+)-for-broker-(.+)$`), "broker", "-for-broker"},
+	{regexp.MustCompile(`^(.+)-for-topic-(.+)$`), "topic", "-for-topic"},
+	{regexp.MustCompile(`^(protocol-requests-rate)-(\d+)$`), "api_key", ""},
+	{regexp.MustCompile(`^(consumer-group-(?:join|sync)-(?:total|failed))-(.+)$`), "group", ""},
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// BridgeSarama exports the go-metrics registry sarama records config's
+// client metrics in, such as request latency and bytes sent per broker, as
+// Prometheus metrics prefixed with sarama_ and labelled with client. It must
+// be called once per config, before the config is used to create a client.
+func BridgeSarama(client string, config *sarama.Config) {
+	prometheus.MustRegister(&saramaCollector{client: client, registry: config.MetricRegistry})
+}
+
+// saramaCollector converts sarama's metrics when they are scraped. It is an
+// unchecked collector, since the metrics sarama records depend on the
+// brokers and topics it has talked to.
+type saramaCollector struct {
+	client   string
+	registry gometrics.Registry
+}
+
+func (c *saramaCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c *saramaCollector) Collect(ch chan<- prometheus.Metric) {
+	c.registry.Each(func(name string, metric interface{}) {
+		base, suffix, labels, values := parseSaramaName(name)
+		labels = append([]string{"client"}, labels...)
+		values = append([]string{c.client}, values...)
+		help := "Sarama client metric " + base + "."
+
+		switch m := metric.(type) {
+		case gometrics.Meter:
+			desc := prometheus.NewDesc(saramaMetricName(strings.TrimSuffix(base, "-rate")+suffix)+"_total", help, labels, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(m.Count()), values...)
+		case gometrics.Histogram:
+			snapshot := m.Snapshot()
+			percentiles := snapshot.Percentiles(saramaQuantiles)
+			quantiles := make(map[float64]float64, len(saramaQuantiles))
+			for i, q := range saramaQuantiles {
+				quantiles[q] = percentiles[i]
+			}
+			desc := prometheus.NewDesc(saramaMetricName(base+suffix), help, labels, nil)
+			ch <- prometheus.MustNewConstSummary(desc, uint64(snapshot.Count()), float64(snapshot.Sum()), quantiles, values...)
+		case gometrics.Counter:
+			// go-metrics counters can go down, as requests-in-flight does.
+			desc := prometheus.NewDesc(saramaMetricName(base+suffix), help, labels, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(m.Count()), values...)
+		case gometrics.Gauge:
+			desc := prometheus.NewDesc(saramaMetricName(base+suffix), help, labels, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(m.Value()), values...)
+		case gometrics.GaugeFloat64:
+			desc := prometheus.NewDesc(saramaMetricName(base+suffix), help, labels, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, m.Value(), values...)
+		}
+	})
+}
+
+// parseSaramaName splits a sarama metric name such as
+// "request-latency-in-ms-for-broker-1" into its base name, the suffix to keep
+// for it, and the labels embedded in it.
+func parseSaramaName(name string) (base, suffix string, labels, values []string) {
+	base = name
+	for _, l := range saramaLabels {
+		match := l.pattern.FindStringSubmatch(base)
+		if match == nil {
+			continue
+		}
+		base = match[1]
+		suffix = l.suffix + suffix
+		labels = append(labels, l.label)
+		values = append(values, match[2])
+	}
+	return base, suffix, labels, values
+}
+
+func saramaMetricName(name string) string {
+	return "sarama_" + invalidNameChars.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,221 @@
+// Package money represents monetary amounts exactly, as an integer number of
+// minor units (such as cents) of an ISO 4217 currency.
+//
+// While services migrate from float64 amounts, a Money is encoded in JSON as
+// a plain number of major units (10.99), exactly like the float fields it
+// replaces, so existing readers keep working. The currency is carried by a
+// separate "currency" field on the enclosing object; see Stamp.
+package money
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// DefaultCurrency is the currency of amounts recorded before amounts carried
+// a currency.
+const DefaultCurrency = "USD"
+
+var ErrInvalidAmount = errors.New("invalid money amount")
+
+// exponents lists the currencies whose minor unit is not a hundredth of the
+// major unit.
+var exponents = map[string]int{
+	"CLP": 0, "ISK": 0, "JPY": 0, "KRW": 0, "VND": 0,
+	"BHD": 3, "JOD": 3, "KWD": 3, "OMR": 3, "TND": 3,
+}
+
+// Exponent returns the number of decimal places of currency's minor unit.
+func Exponent(currency string) int {
+	if exp, ok := exponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 currency code.
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+type Money struct {
+	// Minor is the amount in minor units of Currency.
+	Minor    int64
+	Currency string
+
+	// exact is the amount decoded from JSON before its currency was known,
+	// kept so that Stamp can round it to the right number of decimals.
+	exact *big.Rat
+}
+
+func New(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// Parse parses a decimal amount of major units, such as "10.99", rounding it
+// half away from zero to a whole number of minor units.
+func Parse(amount, currency string) (Money, error) {
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+
+	minor, err := toMinor(r, Exponent(currency))
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", err, amount)
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// Add returns m+o. It panics if their currencies differ.
+func (m Money) Add(o Money) Money {
+	if m.Currency != o.Currency {
+		panic(fmt.Sprintf("money: adding %s to %s", o.Currency, m.Currency))
+	}
+	return Money{Minor: m.Minor + o.Minor, Currency: m.Currency}
+}
+
+// Mul returns m multiplied by n.
+func (m Money) Mul(n int) Money {
+	return Money{Minor: m.Minor * int64(n), Currency: m.Currency}
+}
+
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+// Float64 returns the amount in major units. It is only meant for callers
+// that still deal in float64, such as configured thresholds.
+func (m Money) Float64() float64 {
+	return float64(m.Minor) / math.Pow10(Exponent(m.Currency))
+}
+
+// Decimal formats the amount in major units with the currency's number of
+// decimal places, for example "10.90".
+func (m Money) Decimal() string {
+	exp := Exponent(m.Currency)
+
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	if exp == 0 {
+		return fmt.Sprintf("%s%d", sign, minor)
+	}
+
+	scale := int64(math.Pow10(exp))
+	return fmt.Sprintf("%s%d.%0*d", sign, minor/scale, exp, minor%scale)
+}
+
+// String formats the amount for people, for example "10.90 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// MarshalJSON encodes the amount as a JSON number of major units.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON decodes a number (or numeric string) of major units in the
+// receiver's currency, or DefaultCurrency if it has none. In the latter case
+// the exact amount is also kept until Stamp sets the real currency, so that
+// no decimals are lost to DefaultCurrency's rounding. Binary rounding noise
+// in amounts written as float64, such as 21.000000000000004, is rounded away.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	amount := strings.Trim(string(data), `"`)
+	if m.Currency != "" {
+		parsed, err := Parse(amount, m.Currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	exact, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	minor, err := toMinor(exact, Exponent(DefaultCurrency))
+	if err != nil {
+		return fmt.Errorf("%w: %q", err, amount)
+	}
+	*m = Money{Minor: minor, Currency: DefaultCurrency, exact: exact}
+	return nil
+}
+
+// Stamp sets the currency of amounts decoded from JSON, where they are bare
+// numbers, to currency and returns it. Amounts are rounded from the exact
+// value decoded, or else rescaled from their current currency. An empty
+// currency means the amounts were written before currencies were recorded
+// and are in DefaultCurrency.
+func Stamp(currency string, amounts ...*Money) string {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	for _, m := range amounts {
+		r := m.exact
+		if r == nil {
+			from := Exponent(m.Currency)
+			if m.Currency == "" {
+				from = Exponent(DefaultCurrency)
+			}
+			r = new(big.Rat).SetFrac(big.NewInt(m.Minor), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(from)), nil))
+		}
+		minor, err := toMinor(r, Exponent(currency))
+		if err != nil {
+			minor = m.Minor
+		}
+		*m = Money{Minor: minor, Currency: currency}
+	}
+	return currency
+}
+
+// CurrencyOf returns the currency of the first amount that has one, or
+// DefaultCurrency. It is used to fill in the currency field of an object
+// whose amounts all share a currency.
+func CurrencyOf(amounts ...Money) string {
+	for _, m := range amounts {
+		if m.Currency != "" {
+			return m.Currency
+		}
+	}
+	return DefaultCurrency
+}
+
+// toMinor converts r major units to minor units with exp decimal places,
+// rounding half away from zero.
+func toMinor(r *big.Rat, exp int) (int64, error) {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)))
+
+	num, den := scaled.Num(), scaled.Denom()
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	}
+
+	if !q.IsInt64() {
+		return 0, ErrInvalidAmount
+	}
+	return q.Int64(), nil
+}
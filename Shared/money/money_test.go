@@ -0,0 +1,50 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		json     string
+		currency string
+		want     Money
+		encoded  string
+	}{
+		{json: `10.99`, currency: "USD", want: New(1099, "USD"), encoded: `10.99`},
+		{json: `1.234`, currency: "KWD", want: New(1234, "KWD"), encoded: `1.234`},
+		{json: `"0.005"`, currency: "BHD", want: New(5, "BHD"), encoded: `0.005`},
+		{json: `1250`, currency: "JPY", want: New(1250, "JPY"), encoded: `1250`},
+		{json: `21.000000000000004`, currency: "", want: New(2100, DefaultCurrency), encoded: `21.00`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.json+" "+tt.currency, func(t *testing.T) {
+			var m Money
+			if err := json.Unmarshal([]byte(tt.json), &m); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			Stamp(tt.currency, &m)
+			if m != tt.want {
+				t.Fatalf("decoded %v, want %v", m, tt.want)
+			}
+
+			data, err := json.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.encoded {
+				t.Errorf("encoded %s, want %s", data, tt.encoded)
+			}
+
+			var again Money
+			if err := json.Unmarshal(data, &again); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			Stamp(m.Currency, &again)
+			if again != m {
+				t.Errorf("round trip = %v, want %v", again, m)
+			}
+		})
+	}
+}
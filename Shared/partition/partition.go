@@ -0,0 +1,48 @@
+// Package partition selects the partitioner producers use to map message keys
+// to partitions. Every topic the services produce to is keyed, so only
+// partitioners that honour the key are offered.
+package partition
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+// DefaultPartitioner is used when no partitioner is configured. Like the other
+// hash partitioners it always maps equal keys to the same partition, as long
+// as the topic's partition count does not change.
+const DefaultPartitioner = "hash"
+
+var partitioners = map[string]sarama.PartitionerConstructor{
+	// FNV-1a hash of the key; sarama's default.
+	"hash": sarama.NewHashPartitioner,
+	// CRC32 (IEEE) hash of the key.
+	"crc32": sarama.NewCustomPartitioner(
+		sarama.WithAbsFirst(),
+		sarama.WithCustomHashFunction(crc32.NewIEEE),
+	),
+	// FNV-1a hash using the reference Java client's modulo semantics.
+	"reference": sarama.NewReferenceHashPartitioner,
+}
+
+// Partitioner returns the partitioner registered under name.
+func Partitioner(name string) (sarama.PartitionerConstructor, error) {
+	if name == "" {
+		name = DefaultPartitioner
+	}
+
+	constructor, ok := partitioners[name]
+	if !ok {
+		names := make([]string, 0, len(partitioners))
+		for n := range partitioners {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown partitioner %q (want one of %s)", name, strings.Join(names, ", "))
+	}
+	return constructor, nil
+}
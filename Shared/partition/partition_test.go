@@ -0,0 +1,62 @@
+package partition
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/learning-kafka/Shared/events"
+)
+
+func TestOrderEventsShareAPartition(t *testing.T) {
+	const orderID, numPartitions = 42, 12
+
+	orderEvents := []events.Event{
+		&events.OrderCreated{OrderID: orderID, CustomerID: 7, RestaurantID: 3},
+		&events.OrderCancelled{OrderID: orderID, CustomerID: 7, RestaurantID: 3},
+		&events.PaymentResult{OrderID: orderID, CustomerID: 7, RestaurantID: 3},
+		&events.PaymentRefunded{OrderID: orderID, CustomerID: 7, RestaurantID: 3},
+	}
+
+	key := orderEvents[0].PartitionKey()
+	for _, e := range orderEvents[1:] {
+		if got := e.PartitionKey(); got != key {
+			t.Errorf("%s key = %q, want %q", e.Type(), got, key)
+		}
+	}
+
+	for _, name := range []string{"hash", "crc32", "reference"} {
+		t.Run(name, func(t *testing.T) {
+			constructor, err := Partitioner(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			partitioner := constructor(events.TopicOrderEvents)
+			if !partitioner.RequiresConsistency() {
+				t.Errorf("partitioner does not keep keys on one partition")
+			}
+
+			want := int32(-1)
+			for _, e := range orderEvents {
+				msg := &sarama.ProducerMessage{Topic: events.TopicOrderEvents, Key: sarama.StringEncoder(e.PartitionKey())}
+				got, err := partitioner.Partition(msg, numPartitions)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want == -1 {
+					want = got
+				}
+				if got != want {
+					t.Errorf("%s went to partition %d, want %d", e.Type(), got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestKeylessPartitionersRejected(t *testing.T) {
+	for _, name := range []string{"random", "roundrobin"} {
+		if _, err := Partitioner(name); err == nil {
+			t.Errorf("Partitioner(%q) succeeded, want an error", name)
+		}
+	}
+}
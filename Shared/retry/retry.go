@@ -0,0 +1,198 @@
+// Package retry routes messages that failed transiently through delay
+// topics, such as payment-events.retry.5s, before giving up and sending them
+// to the dead-letter topic.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/learning-kafka/Shared/dlq"
+	"github.com/learning-kafka/Shared/metrics"
+)
+
+// HeaderNotBefore holds the Unix time in milliseconds before which a retried
+// message must not be processed.
+const HeaderNotBefore = "x-not-before"
+
+// Policy describes the retry tiers. A message that fails on its nth attempt
+// is retried after Delays[n-1] (or the last delay once the tiers run out),
+// until MaxAttempts attempts have failed. Delays must be whole seconds, since
+// retry topics are named after them.
+type Policy struct {
+	Delays      []time.Duration
+	MaxAttempts int
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		Delays:      []time.Duration{5 * time.Second, time.Minute, 10 * time.Minute},
+		MaxAttempts: 4,
+	}
+}
+
+// Topics returns topic followed by its retry topics, which is the set of
+// topics a consumer using this policy must subscribe to.
+func (p Policy) Topics(topic string) []string {
+	topics := []string{topic}
+	for _, delay := range p.Delays {
+		topics = append(topics, Topic(topic, delay))
+	}
+	return topics
+}
+
+// Topic returns the name of the retry topic for topic with the given delay,
+// e.g. "payment-events.retry.5s". Fractions of a second are dropped from the
+// name, so delays that differ only by them share a topic.
+func Topic(topic string, delay time.Duration) string {
+	var suffix string
+	switch {
+	case delay%time.Hour == 0:
+		suffix = fmt.Sprintf("%dh", delay/time.Hour)
+	case delay%time.Minute == 0:
+		suffix = fmt.Sprintf("%dm", delay/time.Minute)
+	default:
+		suffix = fmt.Sprintf("%ds", delay/time.Second)
+	}
+	return topic + ".retry." + suffix
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one that retrying cannot fix, such as a malformed
+// message, so that Router.Fail sends it straight to the dead-letter topic.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+type Router struct {
+	producer    sarama.SyncProducer
+	deadLetters *dlq.Publisher
+	policy      Policy
+	service     string
+}
+
+func NewRouter(producer sarama.SyncProducer, policy Policy, service string) *Router {
+	return &Router{
+		producer:    producer,
+		deadLetters: dlq.NewPublisher(producer, service),
+		policy:      policy,
+		service:     service,
+	}
+}
+
+// Fail routes a message whose processing failed with cause: to the next retry
+// topic if attempts remain, otherwise to the dead-letter topic. The caller
+// should mark msg as consumed only if Fail succeeds.
+func (r *Router) Fail(msg *sarama.ConsumerMessage, cause error) error {
+	attempt := dlq.Attempts(msg)
+
+	var permanent *permanentError
+	if errors.As(cause, &permanent) || attempt >= r.policy.MaxAttempts || len(r.policy.Delays) == 0 {
+		return r.deadLetters.Publish(withoutNotBefore(msg), cause)
+	}
+
+	tier := attempt - 1
+	if tier >= len(r.policy.Delays) {
+		tier = len(r.policy.Delays) - 1
+	}
+	delay := r.policy.Delays[tier]
+
+	// The first retry records where the message was originally consumed
+	// from; later retries carry those headers forward unchanged.
+	topic := dlq.Header(msg, dlq.HeaderOriginalTopic)
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+7)
+	if topic == "" {
+		topic = msg.Topic
+		headers = append(headers,
+			header(dlq.HeaderOriginalTopic, topic),
+			header(dlq.HeaderOriginalPartition, strconv.Itoa(int(msg.Partition))),
+			header(dlq.HeaderOriginalOffset, strconv.FormatInt(msg.Offset, 10)),
+		)
+	}
+
+	for _, h := range msg.Headers {
+		switch string(h.Key) {
+		case dlq.HeaderAttempts, dlq.HeaderError, dlq.HeaderService, HeaderNotBefore:
+			continue
+		}
+		headers = append(headers, *h)
+	}
+	headers = append(headers,
+		header(dlq.HeaderAttempts, strconv.Itoa(attempt)),
+		header(dlq.HeaderError, cause.Error()),
+		header(dlq.HeaderService, r.service),
+		header(HeaderNotBefore, strconv.FormatInt(time.Now().Add(delay).UnixMilli(), 10)),
+	)
+
+	_, _, err := r.producer.SendMessage(&sarama.ProducerMessage{
+		Topic:   Topic(topic, delay),
+		Key:     sarama.ByteEncoder(msg.Key),
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.Retried(r.service, topic)
+	return nil
+}
+
+// Owns reports whether msg should be processed by this router's service.
+// Retry topics are shared by every service consuming the original topic, so
+// retries scheduled by other services are skipped.
+func (r *Router) Owns(msg *sarama.ConsumerMessage) bool {
+	if dlq.Header(msg, HeaderNotBefore) == "" {
+		return true
+	}
+	return dlq.Header(msg, dlq.HeaderService) == r.service
+}
+
+// Wait blocks until msg may be processed according to its not-before header.
+// It returns false if ctx is done first, in which case msg must not be marked.
+func Wait(ctx context.Context, msg *sarama.ConsumerMessage) bool {
+	notBefore, err := strconv.ParseInt(dlq.Header(msg, HeaderNotBefore), 10, 64)
+	if err != nil {
+		return true
+	}
+
+	delay := time.Until(time.UnixMilli(notBefore))
+	if delay <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func withoutNotBefore(msg *sarama.ConsumerMessage) *sarama.ConsumerMessage {
+	stripped := *msg
+	stripped.Headers = make([]*sarama.RecordHeader, 0, len(msg.Headers))
+	for _, h := range msg.Headers {
+		if string(h.Key) != HeaderNotBefore {
+			stripped.Headers = append(stripped.Headers, h)
+		}
+	}
+	return &stripped
+}
+
+func header(key, value string) sarama.RecordHeader {
+	return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
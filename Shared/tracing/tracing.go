@@ -0,0 +1,220 @@
+// Package tracing sets up OpenTelemetry tracing and carries W3C trace
+// context through Kafka message headers, so that an order can be followed
+// from the HTTP request that created it through every service that handles
+// its events.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/Shopify/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/learning-kafka/Shared/tracing"
+
+// Init installs the global tracer provider and W3C trace context propagator
+// for service. Spans are exported according to OTEL_TRACES_EXPORTER: "otlp"
+// sends them over OTLP/HTTP to OTEL_EXPORTER_OTLP_ENDPOINT (default
+// http://localhost:4318), "console" or "stdout" prints them, and "none", the
+// default, drops them while still propagating trace context. The returned
+// function flushes pending spans and must be called before the process
+// exits.
+func Init(ctx context.Context, service string) (func(context.Context) error, error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(service)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	switch exporter := os.Getenv("OTEL_TRACES_EXPORTER"); exporter {
+	case "", "none":
+	case "otlp":
+		exp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exp))
+	case "console", "stdout":
+		exp, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exp))
+	default:
+		return nil, fmt.Errorf("invalid OTEL_TRACES_EXPORTER: %q", exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// StartProducer starts the span for publishing msg as a child of ctx and
+// injects its trace context into msg's headers, replacing any already there.
+func StartProducer(ctx context.Context, msg *sarama.ProducerMessage) (context.Context, trace.Span) {
+	ctx, span := tracer().Start(ctx, msg.Topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypePublish,
+			semconv.MessagingDestinationName(msg.Topic),
+		),
+	)
+	if msg.Key != nil {
+		if key, err := msg.Key.Encode(); err == nil {
+			span.SetAttributes(semconv.MessagingKafkaMessageKey(string(key)))
+		}
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, producerCarrier{msg})
+	return ctx, span
+}
+
+// StartConsumer starts the span for group processing msg, continuing the
+// trace whose context msg's headers carry.
+func StartConsumer(ctx context.Context, group string, msg *sarama.ConsumerMessage) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, consumerCarrier{msg})
+	return tracer().Start(ctx, msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeDeliver,
+			semconv.MessagingDestinationName(msg.Topic),
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(int(msg.Partition))),
+			semconv.MessagingKafkaMessageOffset(int(msg.Offset)),
+			semconv.MessagingKafkaConsumerGroup(group),
+		),
+	)
+}
+
+// StartRequest starts the server span for an HTTP request matching route,
+// continuing the caller's trace if the request carries trace context.
+func StartRequest(r *http.Request, route string) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return tracer().Start(ctx, r.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(r.URL.Path),
+		),
+	)
+}
+
+// EndRequest records the response status on span and ends it. Server errors
+// mark the span as failed.
+func EndRequest(span trace.Span, status int) {
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	span.End()
+}
+
+// End records err, if any, on span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject returns ctx's trace context as a map of headers, for messages that
+// are published later, such as those waiting in an outbox.
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract returns ctx carrying the trace context saved by Inject.
+func Extract(ctx context.Context, headers map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headers))
+}
+
+// producerCarrier and consumerCarrier let the propagator read and write
+// Kafka record headers.
+type producerCarrier struct {
+	msg *sarama.ProducerMessage
+}
+
+func (c producerCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c producerCarrier) Set(key, value string) {
+	for i, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c producerCarrier) Keys() []string {
+	keys := make([]string, len(c.msg.Headers))
+	for i, h := range c.msg.Headers {
+		keys[i] = string(h.Key)
+	}
+	return keys
+}
+
+type consumerCarrier struct {
+	msg *sarama.ConsumerMessage
+}
+
+func (c consumerCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set is never called, since consumed messages are only extracted from.
+func (c consumerCarrier) Set(string, string) {}
+
+func (c consumerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		if h != nil {
+			keys = append(keys, string(h.Key))
+		}
+	}
+	return keys
+}